@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// DiagramCmd draws the relationships between types in outline documents
+var DiagramCmd = &cobra.Command{
+	Use:   "diagram",
+	Short: "draw a diagram of the types in outline documents",
+	Long: `diagram writes the types declared in outline documents as a graphviz DOT
+graph or mermaid class diagram. Fields connect a type to the types it contains,
+methods & operators connect a type to the types they produce.
+
+  outline diagram geo.outline | dot -Tsvg > geo.svg`,
+	Run: func(cmd *cobra.Command, args []string) {
+		docs, err := loadFiles(cmd, args)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		table, diags := docs.Resolve()
+		for _, d := range diags {
+			log.Warn(d.String())
+		}
+
+		format, err := cmd.Flags().GetString("format")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		switch format {
+		case "dot":
+			err = table.WriteDot(os.Stdout, docs)
+		case "mermaid":
+			err = table.WriteMermaid(os.Stdout, docs)
+		default:
+			err = fmt.Errorf("unknown diagram format %q. available formats: dot, mermaid", format)
+		}
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	DiagramCmd.Flags().StringP("format", "f", "dot", "diagram format. one of: dot, mermaid")
+}
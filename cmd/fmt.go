@@ -1,58 +1,117 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/b5/outline/lib"
 	"github.com/spf13/cobra"
 )
 
-// FmtCmd prints present configuration info
+// FmtCmd formats outline documents
 var FmtCmd = &cobra.Command{
 	Use:   "fmt",
 	Short: "format input",
-	Long:  ``,
+	Long: `fmt re-indents outline documents, keeping comments, blank lines & the
+order of elements intact. Documents are sorted by name unless --no-sort is
+set, only trading places with documents they're separated from by blank
+lines. Documents in go source files are formatted in place within their
+comments, leaving go code untouched. Formatted files are printed to stdout
+unless --write is set`,
 	Run: func(cmd *cobra.Command, args []string) {
-		var docs lib.Docs
+		prefix, err := indentPrefix(cmd)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		write, err := cmd.Flags().GetBool("write")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		noSort, err := cmd.Flags().GetBool("no-sort")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		options, err := parseOptions(cmd)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
 		for _, fp := range args {
-			f, err := os.Open(fp)
+			src, err := ioutil.ReadFile(fp)
 			if err != nil {
 				fmt.Println(err.Error())
 				os.Exit(1)
 			}
 
-			found, err := lib.Parse(f)
+			tree, err := lib.ParseSyntax(src, lib.Filename(fp))
 			if err != nil {
 				fmt.Println(err.Error())
 				os.Exit(1)
 			}
-			docs = append(docs, found...)
-		}
-
-		noSort, err := cmd.Flags().GetBool("no-sort")
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-		if !noSort {
-			docs.Sort()
-		}
-
-		for _, doc := range docs {
-			data, err := doc.MarshalIndent(0, "  ")
+			// includes aren't expanded, formatting preserves include directives
+			docs, err := tree.Docs(options...)
 			if err != nil {
 				fmt.Println(err.Error())
 				os.Exit(1)
 			}
+			for _, doc := range docs {
+				for _, d := range doc.Diagnostics() {
+					log.Warn(d.String())
+				}
+			}
 
-			fmt.Print(string(data) + "\n")
+			if !noSort {
+				tree.SortDocuments()
+			}
+			tree.Format(prefix)
+			data := tree.Bytes()
+			if !write {
+				fmt.Print(string(data))
+				continue
+			}
+			if bytes.Equal(data, src) {
+				continue
+			}
+			log.Infof("formatted %s", fp)
+			if err := ioutil.WriteFile(fp, data, 0644); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
 		}
-
 	},
 }
 
+// indentPrefix returns the indentation string selected by command flags
+func indentPrefix(cmd *cobra.Command) (string, error) {
+	tabs, err := cmd.Flags().GetBool("tabs")
+	if err != nil {
+		return "", err
+	}
+	if tabs {
+		return "\t", nil
+	}
+
+	width, err := cmd.Flags().GetInt("indent")
+	if err != nil {
+		return "", err
+	}
+	if width < 1 {
+		return "", fmt.Errorf("indent must be greater than zero, got %d", width)
+	}
+	return strings.Repeat(" ", width), nil
+}
+
 func init() {
 	// FmtCmd.Flags().StringP("export", "e", "config.json", "path to configuration json file")
-	FmtCmd.Flags().Bool("no-sort", false, "done alpha-sort fields & outline documents")
+	FmtCmd.Flags().BoolP("write", "w", false, "write formatted output back to source files instead of stdout")
+	FmtCmd.Flags().Bool("no-sort", false, "don't alpha-sort outline documents")
+	FmtCmd.Flags().Int("indent", 2, "number of spaces per indentation level in formatted output")
+	FmtCmd.Flags().Bool("tabs", false, "indent formatted output with tabs instead of spaces")
 }
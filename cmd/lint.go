@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/b5/outline/lib"
+	"github.com/b5/outline/lib/edit"
+	"github.com/spf13/cobra"
+)
+
+// LintCmd reports problems in outline documents, repairing those it can
+var LintCmd = &cobra.Command{
+	Use:   "lint [files...]",
+	Short: "check outline documents for problems",
+	Long: `lint reports the warnings & errors found parsing outline documents, along
+with problems that can be repaired automatically:
+
+  misspelled keywords, like "parms:"
+  mixed tabs & spaces in indentation
+  functions with arguments in their signature & no params section
+  params that aren't in the signature of their function
+
+Pass --fix to repair them, writing fixed files back in place. Only the lines
+that need fixing are edited, so documents in go source files are fixed within
+their comments, leaving go code untouched. lint exits with a non-zero status
+when problems remain`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		fix, err := cmd.Flags().GetBool("fix")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		options, err := parseOptions(cmd)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		problems, fixable := 0, 0
+		for _, fp := range args {
+			src, err := ioutil.ReadFile(fp)
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+
+			tree, fixes, err := lint(fp, src, options)
+			if err != nil {
+				fmt.Printf("%s: %s\n", fp, err)
+				os.Exit(1)
+			}
+			if fix && len(fixes) > 0 {
+				fixed := src
+				// fixes that overlap an applied fix are found again by linting
+				// the fixed source, repeat until there's nothing left to fix
+				for i := 0; i < 10 && len(fixes) > 0; i++ {
+					if fixed, _, err = edit.ApplyFixes(fixed, fixes); err != nil {
+						fmt.Printf("%s: %s\n", fp, err)
+						os.Exit(1)
+					}
+					if tree, fixes, err = lint(fp, fixed, options); err != nil {
+						fmt.Printf("%s: %s\n", fp, err)
+						os.Exit(1)
+					}
+				}
+				if !bytes.Equal(fixed, src) {
+					log.Infof("fixed %s", fp)
+					if err := ioutil.WriteFile(fp, fixed, 0644); err != nil {
+						fmt.Println(err.Error())
+						os.Exit(1)
+					}
+				}
+			}
+
+			diags, err := diagnostics(tree, fixes, options)
+			if err != nil {
+				fmt.Printf("%s: %s\n", fp, err)
+				os.Exit(1)
+			}
+			for _, d := range diags {
+				fmt.Println(d.String())
+			}
+			problems += len(diags)
+			fixable += len(fixes)
+		}
+
+		if fixable > 0 {
+			log.Infof("%d problems can be fixed with --fix", fixable)
+		}
+		if problems > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+// lint reads the syntax tree of a file & finds fixes for it
+func lint(fp string, src []byte, options []lib.Option) (*lib.SyntaxTree, []edit.Fix, error) {
+	tree, err := lib.ParseSyntax(src, lib.Filename(fp))
+	if err != nil {
+		return nil, nil, err
+	}
+	fixes, err := edit.Lint(tree, options...)
+	return tree, fixes, err
+}
+
+// diagnostics combines the diagnostics of parsing a tree with those of fixes,
+// dropping fixes the parser reported in the same place, ordered by position
+func diagnostics(tree *lib.SyntaxTree, fixes []edit.Fix, options []lib.Option) (lib.Diagnostics, error) {
+	var diags lib.Diagnostics
+	docs, err := tree.Docs(options...)
+	if errs, ok := err.(lib.Diagnostics); ok {
+		diags = errs
+	} else if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs.Modules() {
+		diags = append(diags, doc.Diagnostics()...)
+	}
+
+	seen := map[string]bool{}
+	for _, d := range diags {
+		seen[fmt.Sprintf("%d:%s", d.Pos.Line, d.Message)] = true
+	}
+	for _, fix := range fixes {
+		if !seen[fmt.Sprintf("%d:%s", fix.Pos.Line, fix.Message)] {
+			diags = append(diags, fix.Diagnostic)
+		}
+	}
+	sort.SliceStable(diags, func(i, j int) bool {
+		if diags[i].Pos.Line != diags[j].Pos.Line {
+			return diags[i].Pos.Line < diags[j].Pos.Line
+		}
+		return diags[i].Pos.Col < diags[j].Pos.Col
+	})
+	return diags, nil
+}
+
+func init() {
+	LintCmd.Flags().Bool("fix", false, "repair problems that have an automatic fix, writing fixed files back in place")
+}
@@ -3,62 +3,53 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"strings"
-	"text/template"
 
 	"github.com/b5/outline/lib"
+	"github.com/b5/outline/lib/extract"
 	"github.com/spf13/cobra"
-	parseutil "gopkg.in/src-d/go-parse-utils.v1"
 )
 
 // PackageCmd extracts and execute outline documents from a go package against a template",
 var PackageCmd = &cobra.Command{
-	Use:     "package",
+	Use:     "package [packages]",
 	Aliases: []string{"pkg"},
 	Short:   "exctract and execute outline documents from a go package against a template",
 	Long:    ``,
 	Run: func(cmd *cobra.Command, args []string) {
-		t := template.Must(template.New("mdIndex").Parse(mdIndex))
-
-		str, err := cmd.Flags().GetString("template")
+		e, err := extractor(cmd)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
-		if str != "" {
-			t, err = template.ParseFiles(str)
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
-		}
-
-		docs := map[string]*lib.Doc{}
-		for _, pkg := range args {
-			pkg, err := parseutil.PackageAST(pkg)
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
 
-			for _, f := range pkg.Files {
-				for _, c := range f.Comments {
-					buf := strings.NewReader(c.Text())
-					read, err := lib.Parse(buf)
-					if err != nil {
-						fmt.Println(err.Error())
-						os.Exit(1)
-					}
+		// documents are collected in source order: packages in the order they're
+		// given, files sorted by name, then comments in the order they appear.
+		// merging keeps that order, so unsorted output is stable between runs
+		docs, err := e.Packages(args...)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 
-					for _, doc := range read {
-						if found, ok := docs[doc.Name]; ok {
-							merge(found, doc)
-							continue
-						}
+		precedence, err := cmd.Flags().GetString("merge-precedence")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		prec, err := lib.ParsePrecedence(precedence)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 
-						docs[doc.Name] = doc
-					}
-				}
+		list, err := docs.Merge(lib.MergePrecedence(prec))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		for _, doc := range list {
+			for _, d := range doc.Diagnostics() {
+				log.Warn(d.String())
 			}
 		}
 
@@ -67,37 +58,58 @@ var PackageCmd = &cobra.Command{
 			fmt.Println(err)
 			os.Exit(1)
 		}
-
-		var list lib.Docs
-		for _, doc := range docs {
-			list = append(list, doc)
-		}
-
 		if !noSort {
 			list.Sort()
 		}
 
-		if err := t.Execute(os.Stdout, list); err != nil {
+		if err := render(cmd, list); err != nil {
 			fmt.Println(err.Error())
 			os.Exit(1)
 		}
 	},
 }
 
-func merge(a, b *lib.Doc) {
-	if a.Description == "" {
-		a.Description = b.Description
+// extractor configures go source extraction from command flags
+func extractor(cmd *cobra.Command) (*extract.Extractor, error) {
+	jobs, err := cmd.Flags().GetInt("jobs")
+	if err != nil {
+		return nil, err
+	}
+	options, err := parseOptions(cmd)
+	if err != nil {
+		return nil, err
 	}
+	e := &extract.Extractor{Workers: jobs, Options: options}
 
-	if a.Path == "" {
-		a.Path = b.Path
+	noCache, err := cmd.Flags().GetBool("no-cache")
+	if err != nil {
+		return nil, err
+	}
+	if noCache {
+		return e, nil
 	}
 
-	a.Types = append(a.Types, b.Types...)
-	a.Functions = append(a.Functions, b.Functions...)
+	dir, err := cmd.Flags().GetString("cache-dir")
+	if err != nil {
+		return nil, err
+	}
+	if dir == "" {
+		if dir, err = extract.DefaultCacheDir(); err != nil {
+			log.Warnf("caching disabled: %s", err)
+			return e, nil
+		}
+	}
+	if e.Cache, err = extract.NewCache(dir); err != nil {
+		log.Warnf("caching disabled: %s", err)
+	}
+	return e, nil
 }
 
 func init() {
-	PackageCmd.Flags().StringP("template", "t", "", "template file to load. overrides preset")
+	addTemplateFlags(PackageCmd)
 	PackageCmd.Flags().Bool("no-sort", false, "don't alpha-sort fields & outline documents")
+	PackageCmd.Flags().IntP("jobs", "j", 0, "number of files to read at once. defaults to the number of CPUs")
+	PackageCmd.Flags().Bool("no-cache", false, "don't cache outline comments read from go files")
+	PackageCmd.Flags().String("cache-dir", "", "directory to cache outline comments in. defaults to the user cache directory")
+	PackageCmd.Flags().String("merge-precedence", "first", "description & path to keep when merging documents that share a name. one of: first, last, equal")
 }
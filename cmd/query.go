@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/template"
+
+	"github.com/b5/outline/lib"
+	"github.com/spf13/cobra"
+)
+
+// QueryCmd prints elements of outline documents that match a selector
+var QueryCmd = &cobra.Command{
+	Use:   "query [selector] [files...]",
+	Short: "select elements from outline documents",
+	Long: `query prints the elements of outline documents that match a selector.
+Selectors are dot-separated paths that start with a document name, with
+optional filters in square brackets:
+
+  outline query 'geo.types.point.methods[name=buffer]' geo.outline
+  outline query '*.functions[!examples]' *.go
+  outline query '**[return=duration]' *.go
+
+steps after the document name select a section (functions, types, methods,
+fields, params, operators, examples), match elements by name or glob, or
+select all nested elements with "**". filters test an attribute with
+[attr], [!attr], [attr=value], [attr!=value], [attr^=value], [attr$=value]
+or [attr*=value].`,
+	Args: cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		q, err := lib.ParseQuery(args[0])
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		docs, err := loadFiles(cmd, args[1:])
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		matches := q.Select(docs)
+
+		if err := printMatches(cmd, docs, matches); err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+	},
+}
+
+// printMatches writes query results in the format selected by command flags
+func printMatches(cmd *cobra.Command, docs lib.Docs, matches []*lib.Match) error {
+	text, err := cmd.Flags().GetString("template")
+	if err != nil {
+		return err
+	}
+	if text != "" {
+		table, _ := docs.Resolve()
+		t, err := template.New("query").Funcs(lib.TemplateFuncs(table)).Parse(text)
+		if err != nil {
+			return err
+		}
+		for _, m := range matches {
+			if err := t.Execute(os.Stdout, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "outline":
+		for i, m := range matches {
+			data, err := lib.MarshalNode(m.Node, "  ")
+			if err != nil {
+				return err
+			}
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Print(string(data))
+		}
+		return nil
+	case "json":
+		results := make([]map[string]interface{}, len(matches))
+		for i, m := range matches {
+			results[i] = map[string]interface{}{
+				"name":  m.Name(),
+				"kind":  m.Kind(),
+				"value": m.Node,
+			}
+		}
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	default:
+		return fmt.Errorf("unknown query output format %q. available formats: outline, json", format)
+	}
+}
+
+func init() {
+	QueryCmd.Flags().StringP("format", "f", "outline", "output format. one of: outline, json")
+	QueryCmd.Flags().StringP("template", "t", "", "template executed for each match, eg: '{{ .Name }} {{ .Kind }}{{ \"\\n\" }}'")
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/b5/outline/lib"
+	"github.com/b5/outline/lib/edit"
+	"github.com/spf13/cobra"
+)
+
+// RenameCmd renames an element of outline documents & references to it
+var RenameCmd = &cobra.Command{
+	Use:   "rename [name] [new name] [files...]",
+	Short: "rename a function, type, method, field or param",
+	Long: `rename changes the name of an outline element & updates references to it
+across every file given. name is qualified with the names of the elements
+that contain it, & the new name is unqualified. --kind picks between elements
+that share a name, like a type & the function that makes it:
+
+  outline rename geo.point coord --kind type *.go
+  outline rename geo.point.buffer expand --kind method geo.outline
+
+types are renamed in params, fields, return values & operators that use them,
+params in the signature of their function, & any element in description code
+spans that name it, like ` + "`geo.point`" + `. Only changed lines are edited,
+keeping comments & formatting intact. Edited files are printed to stdout
+unless --write is set`,
+	Args: cobra.MinimumNArgs(3),
+	Run: func(cmd *cobra.Command, args []string) {
+		name, newName := args[0], args[1]
+		write, err := cmd.Flags().GetBool("write")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		kinds, err := cmd.Flags().GetStringSlice("kind")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		renamed := false
+		for _, fp := range args[2:] {
+			src, err := ioutil.ReadFile(fp)
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			tree, err := lib.ParseSyntax(src, lib.Filename(fp))
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			edits, err := edit.Rename(tree, name, newName, kinds...)
+			if err != nil {
+				fmt.Printf("%s: %s\n", fp, err)
+				os.Exit(1)
+			}
+			if len(edits) == 0 {
+				continue
+			}
+			renamed = true
+
+			data, err := edit.Apply(src, edits)
+			if err != nil {
+				fmt.Printf("%s: %s\n", fp, err)
+				os.Exit(1)
+			}
+			if !write {
+				fmt.Print(string(data))
+				continue
+			}
+			log.Infof("renamed %s in %s", name, fp)
+			if err := ioutil.WriteFile(fp, data, 0644); err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+		}
+
+		if !renamed {
+			fmt.Printf("no element named %s\n", name)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	RenameCmd.Flags().BoolP("write", "w", false, "write edited files back in place instead of to stdout")
+	RenameCmd.Flags().StringSlice("kind", nil, "only rename elements of a kind: function, method, type, field or param")
+}
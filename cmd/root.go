@@ -32,9 +32,15 @@ func Execute() {
 
 func init() {
 	RootCmd.PersistentFlags().Bool("debug", false, "show debug output")
+	RootCmd.PersistentFlags().Bool("strict", false, "fail on unknown keywords, misplaced sections & text that isn't part of any element")
 	RootCmd.AddCommand(
 		FmtCmd,
 		TemplateCmd,
 		PackageCmd,
+		DiagramCmd,
+		SearchIndexCmd,
+		QueryCmd,
+		RenameCmd,
+		LintCmd,
 	)
 }
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/b5/outline/lib"
+	"github.com/spf13/cobra"
+)
+
+// SearchIndexCmd writes a JSON search index of outline documents
+var SearchIndexCmd = &cobra.Command{
+	Use:   "search-index",
+	Short: "write a JSON search index of outline documents",
+	Long: `search-index writes a JSON index with an entry for each module, function,
+method, type, field & example in outline documents, for client-side search
+with libraries like lunr. Use the same --filename pattern given to
+"outline template --out-dir" so entry URLs point to generated pages.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		docs, err := loadFiles(cmd, args)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		table, diags := docs.Resolve()
+		for _, d := range diags {
+			log.Warn(d.String())
+		}
+
+		pattern, err := cmd.Flags().GetString("filename")
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		if pattern != "" {
+			names, err := lib.Filenames(table, docs, pattern)
+			if err != nil {
+				fmt.Println(err.Error())
+				os.Exit(1)
+			}
+			table.SetPages(func(doc *lib.Doc) string { return names[doc] })
+		}
+
+		data, err := json.MarshalIndent(table.SearchIndex(docs), "", "  ")
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	},
+}
+
+func init() {
+	SearchIndexCmd.Flags().String("filename", "", "template for the file each document is written to, eg: \"{{ .Name }}.md\". entries link to anchors on a single page if empty")
+}
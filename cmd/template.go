@@ -10,70 +10,6 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// backticks don't work with golang string literals, so use "'" as a stand-in & strings.Replace
-var mdIndex = strings.Replace(`{{- define "mdFn" }}
-#### '{{ .Signature }}'
-{{- if ne .Description "" }}
-{{ .Description }}
-{{- end -}}
-{{- if gt (len .Params) 0 }}
-
-**parameters:**
-
-| name | type | description |
-|------|------|-------------|
-{{ range .Params -}}
-| '{{ .Name }}' | '{{ .Type }}' | {{ .Description }} |
-{{ end -}}
-{{- end -}}
-{{- end -}}
-
-{{- range . -}}
-# {{ .Name }}
-{{ if ne .Description "" }}{{ .Description }}{{ end }}
-{{- if gt (len .Functions) 0 }}
-
-## Functions
-{{ range .Functions -}}
-{{ template "mdFn" . }}
-{{ end -}}
-{{- end }}
-{{ if gt (len .Types) 0 }}
-## Types
-
-{{ range .Types -}}
-### '{{ .Name }}'
-{{ if ne .Description "" }}{{ .Description }}{{ end -}}
-{{ if gt (len .Fields) 0 }}
-
-**Fields**
-
-| name | type | description |
-|------|------|-------------|
-{{ range .Fields -}}
-| {{ .Name }} | {{ .Type }} | {{ .Description }} |
-{{ end -}}
-{{ end -}}
-{{ if gt (len .Methods) 0 }}
-**Methods**
-{{- range .Methods -}}
-{{ template "mdFn" . }}
-{{ end -}}
-{{- if gt (len .Operators) 0 }}
-
-**Operators**
-
-| operator | description |
-|----------|-------------|
-{{ range .Operators -}}
-	| {{ .Opr }} | {{ .Description }} |
-{{ end }}
-{{ end }}
-{{ end }}
-{{- end -}}
-{{- end -}}
-{{ end }}`, "'", "`", -1)
-
 // TemplateCmd parses outline documents & executes them against a template
 var TemplateCmd = &cobra.Command{
 	Use:     "template",
@@ -81,21 +17,6 @@ var TemplateCmd = &cobra.Command{
 	Short:   "execute outline documents against a template",
 	Long:    ``,
 	Run: func(cmd *cobra.Command, args []string) {
-		t := template.Must(template.New("mdIndex").Parse(mdIndex))
-
-		str, err := cmd.Flags().GetString("template")
-		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
-		if str != "" {
-			t, err = template.ParseFiles(str)
-			if err != nil {
-				fmt.Println(err)
-				os.Exit(1)
-			}
-		}
-
 		var options []lib.Option
 		shouldSort, err := cmd.Flags().GetBool("sort")
 		if err != nil {
@@ -106,30 +27,132 @@ var TemplateCmd = &cobra.Command{
 			options = append(options, lib.AlphaSortFuncs(), lib.AlphaSortTypes())
 		}
 
-		var docs lib.Docs
-		for _, fp := range args {
-			f, err := os.Open(fp)
-			if err != nil {
-				fmt.Println(err.Error())
-				os.Exit(1)
-			}
-
-			read, err := lib.Parse(f, options...)
-			if err != nil {
-				fmt.Println(err.Error())
-				os.Exit(1)
-			}
-			docs = append(docs, read...)
+		docs, err := loadFiles(cmd, args, options...)
+		if err != nil {
+			fmt.Println(err.Error())
+			os.Exit(1)
 		}
 
-		if err := t.Execute(os.Stdout, docs); err != nil {
+		if err := render(cmd, docs); err != nil {
 			fmt.Println(err.Error())
 			os.Exit(1)
 		}
 	},
 }
 
+// loadFiles reads outline documents from a list of files, expanding
+// includes & logging any diagnostics
+func loadFiles(cmd *cobra.Command, paths []string, options ...lib.Option) (docs lib.Docs, err error) {
+	parseOpts, err := parseOptions(cmd)
+	if err != nil {
+		return nil, err
+	}
+	options = append(options, parseOpts...)
+	for _, fp := range paths {
+		read, err := lib.LoadFile(fp, options...)
+		if err != nil {
+			return nil, err
+		}
+		for _, doc := range read {
+			for _, d := range doc.Diagnostics() {
+				log.Warn(d.String())
+			}
+		}
+		docs = append(docs, read...)
+	}
+	return docs, nil
+}
+
+// parseOptions returns the parsing options selected by global flags
+func parseOptions(cmd *cobra.Command) ([]lib.Option, error) {
+	strict, err := cmd.Flags().GetBool("strict")
+	if err != nil {
+		return nil, err
+	}
+	if strict {
+		return []lib.Option{lib.Strict()}, nil
+	}
+	return nil, nil
+}
+
+// render resolves references between docs & executes the template selected
+// by command flags, writing to stdout, or one file per document when an
+// output directory is set
+func render(cmd *cobra.Command, docs lib.Docs) error {
+	table, diags := docs.Resolve()
+	for _, d := range diags {
+		log.Warn(d.String())
+	}
+
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		return err
+	}
+	paths, err := cmd.Flags().GetStringSlice("template")
+	if err != nil {
+		return err
+	}
+	t, err := lib.LoadTemplate(format, paths, lib.TemplateFuncs(table))
+	if err != nil {
+		return err
+	}
+	diagrams, err := cmd.Flags().GetBool("diagrams")
+	if err != nil {
+		return err
+	}
+	if diagrams {
+		t.Funcs(template.FuncMap{"diagrams": func() bool { return true }})
+	}
+
+	dir, err := cmd.Flags().GetString("out-dir")
+	if err != nil {
+		return err
+	}
+	if dir == "" {
+		return t.Execute(os.Stdout, docs)
+	}
+
+	preset, err := lib.LookupPreset(format)
+	if err != nil {
+		return err
+	}
+	pattern, err := cmd.Flags().GetString("filename")
+	if err != nil {
+		return err
+	}
+	if pattern == "" {
+		pattern = "{{ .Name }}" + preset.Ext
+	}
+	index, err := cmd.Flags().GetString("index")
+	if err != nil {
+		return err
+	}
+	if index == "" && !cmd.Flags().Changed("index") {
+		index = "index" + preset.Ext
+	}
+
+	written, err := lib.WriteDir(t, table, docs, dir, pattern, index)
+	for _, path := range written {
+		log.Infof("wrote %s", path)
+	}
+	return err
+}
+
+// addTemplateFlags registers flags that select a template
+func addTemplateFlags(cmd *cobra.Command) {
+	var formats []string
+	for _, p := range lib.Presets() {
+		formats = append(formats, p.Name)
+	}
+	cmd.Flags().StringP("format", "f", "markdown", "built-in template to use. one of: "+strings.Join(formats, ", "))
+	cmd.Flags().StringSliceP("template", "t", nil, "template files or directories to load over the format. files can override single blocks of the format, like \"mdFn\"")
+	cmd.Flags().StringP("out-dir", "o", "", "write each document to its own file in this directory instead of stdout")
+	cmd.Flags().String("filename", "", "template for output filenames when writing to a directory. defaults to \"{{ .Name }}\" plus the format extension")
+	cmd.Flags().Bool("diagrams", false, "embed a mermaid class diagram of each document's types, if the format supports it")
+	cmd.Flags().String("index", "", "name of the index file written alongside documents. defaults to \"index\" plus the format extension, set to \"\" to skip")
+}
+
 func init() {
-	TemplateCmd.Flags().StringP("template", "t", "", "template file to load. overrides preset")
+	addTemplateFlags(TemplateCmd)
 	TemplateCmd.Flags().Bool("sort", false, "alpha-sort fields & outline documents")
 }
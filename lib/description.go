@@ -0,0 +1,160 @@
+package lib
+
+import "strings"
+
+// Description is descriptive text written in markdown. Lines of a paragraph
+// are joined with spaces, while paragraphs, list items and fenced code blocks
+// are separated by line breaks. Blank lines between paragraphs are preserved
+type Description string
+
+// String implements the stringer interface for Description
+func (d Description) String() string {
+	return string(d)
+}
+
+// BlockType enumerates the kinds of blocks a description is made of
+type BlockType int
+
+const (
+	// ParagraphBlock is a run of text
+	ParagraphBlock BlockType = iota
+	// ListBlock is a run of list items
+	ListBlock
+	// CodeBlock is a fenced block of code
+	CodeBlock
+)
+
+// String implements the stringer interface for BlockType
+func (t BlockType) String() string {
+	switch t {
+	case ParagraphBlock:
+		return "paragraph"
+	case ListBlock:
+		return "list"
+	case CodeBlock:
+		return "code"
+	default:
+		return "unknown"
+	}
+}
+
+// Block is a structural element of a description
+type Block struct {
+	Type BlockType
+	// Text is the content of paragraph & code blocks
+	Text string
+	// Lang is the info string of a fenced code block, eg: "python"
+	Lang string
+	// Items holds the entries of a list block, without list markers
+	Items []string
+	// Ordered is true for numbered lists
+	Ordered bool
+}
+
+// Blocks breaks a description into paragraphs, lists & code blocks
+func (d Description) Blocks() (blocks []*Block) {
+	var cur *Block
+	flush := func() {
+		if cur != nil {
+			blocks = append(blocks, cur)
+			cur = nil
+		}
+	}
+
+	for _, line := range strings.Split(string(d), "\n") {
+		if cur != nil && cur.Type == CodeBlock {
+			if strings.HasPrefix(strings.TrimSpace(line), "```") {
+				flush()
+				continue
+			}
+			if cur.Text != "" || line != "" {
+				cur.Text += line + "\n"
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "```"):
+			flush()
+			cur = &Block{Type: CodeBlock, Lang: strings.TrimSpace(strings.TrimPrefix(trimmed, "```"))}
+		case isHeading(trimmed):
+			// headings are paragraphs of their own
+			flush()
+			blocks = append(blocks, &Block{Type: ParagraphBlock, Text: trimmed})
+		case isListItem(trimmed):
+			ordered := trimmed[0] >= '0' && trimmed[0] <= '9'
+			if cur == nil || cur.Type != ListBlock || cur.Ordered != ordered {
+				flush()
+				cur = &Block{Type: ListBlock, Ordered: ordered}
+			}
+			cur.Items = append(cur.Items, trimListMarker(trimmed))
+		case cur != nil && cur.Type == ListBlock:
+			// lazy continuation of the last list item
+			cur.Items[len(cur.Items)-1] += " " + trimmed
+		case cur != nil && cur.Type == ParagraphBlock:
+			cur.Text += " " + trimmed
+		default:
+			flush()
+			cur = &Block{Type: ParagraphBlock, Text: trimmed}
+		}
+	}
+	flush()
+
+	for _, b := range blocks {
+		if b.Type == CodeBlock {
+			b.Text = strings.TrimSuffix(b.Text, "\n")
+		}
+	}
+	return blocks
+}
+
+// Inline flattens a description to a single line, for use in places where
+// line breaks aren't allowed, like a markdown table cell
+func (d Description) Inline() string {
+	var parts []string
+	for _, b := range d.Blocks() {
+		switch b.Type {
+		case ListBlock:
+			parts = append(parts, b.Items...)
+		case CodeBlock:
+			parts = append(parts, strings.Join(strings.Fields(b.Text), " "))
+		default:
+			parts = append(parts, b.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// isHeading reports whether a line of text is a markdown heading, like
+// "## Details"
+func isHeading(line string) bool {
+	text := strings.TrimLeft(line, "#")
+	n := len(line) - len(text)
+	return n > 0 && n <= 6 && (text == "" || text[0] == ' ')
+}
+
+// isListItem reports whether a line of text begins with a list marker
+func isListItem(line string) bool {
+	return trimListMarker(line) != line
+}
+
+// trimListMarker removes a leading "- ", "* ", "+ " or "1. " from a line
+func trimListMarker(line string) string {
+	for _, m := range []string{"- ", "* ", "+ "} {
+		if strings.HasPrefix(line, m) {
+			return strings.TrimSpace(line[len(m):])
+		}
+	}
+
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i > 0 && strings.HasPrefix(line[i:], ". ") {
+		return strings.TrimSpace(line[i+2:])
+	}
+	return line
+}
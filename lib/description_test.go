@@ -0,0 +1,28 @@
+package lib
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDescriptionBlocks(t *testing.T) {
+	desc := Description("a paragraph\nthat wraps\n## Details\n- one\n- two\n1. first\n\n```go\nfunc() {\n\treturn\n}\n```\ntrailing")
+
+	expect := []*Block{
+		{Type: ParagraphBlock, Text: "a paragraph that wraps"},
+		{Type: ParagraphBlock, Text: "## Details"},
+		{Type: ListBlock, Items: []string{"one", "two"}},
+		{Type: ListBlock, Items: []string{"first"}, Ordered: true},
+		{Type: CodeBlock, Lang: "go", Text: "func() {\n\treturn\n}"},
+		{Type: ParagraphBlock, Text: "trailing"},
+	}
+	if diff := cmp.Diff(expect, desc.Blocks()); diff != "" {
+		t.Errorf("blocks mismatch (-want +got):\n%s", diff)
+	}
+
+	inline := "a paragraph that wraps ## Details one two first func() { return } trailing"
+	if got := desc.Inline(); got != inline {
+		t.Errorf("inline mismatch. expected: %q, got: %q", inline, got)
+	}
+}
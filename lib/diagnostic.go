@@ -0,0 +1,56 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Severity ranks how serious a diagnostic is
+type Severity int
+
+const (
+	// Warning diagnostics describe input that was understood, but is likely a mistake
+	Warning Severity = iota
+	// Error diagnostics describe input that couldn't be understood
+	Error
+)
+
+// String implements the stringer interface for Severity
+func (s Severity) String() string {
+	switch s {
+	case Warning:
+		return "warning"
+	case Error:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnostic is a message about a problem encountered while reading an outline
+type Diagnostic struct {
+	Pos      Position
+	Severity Severity
+	Message  string
+	// Suggestion replaces the word at Pos to fix the problem when there's a
+	// likely fix, like the keyword a misspelled keyword was meant to be
+	Suggestion string
+}
+
+// String implements the stringer interface for Diagnostic
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s: %s: %s", d.Pos, d.Severity, d.Message)
+}
+
+// Diagnostics is a list of diagnostics that can be returned as an error
+type Diagnostics []Diagnostic
+
+// Error implements the error interface, listing each diagnostic on its own
+// line
+func (ds Diagnostics) Error() string {
+	lines := make([]string, len(ds))
+	for i, d := range ds {
+		lines[i] = d.String()
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,203 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RelationKind enumerates the ways one type can relate to another
+type RelationKind int
+
+const (
+	// ContainsRelation is a type with a field of another type
+	ContainsRelation RelationKind = iota
+	// ReturnsRelation is a type with a method that returns another type
+	ReturnsRelation
+	// OperatorRelation is a type with an operator that results in another type
+	OperatorRelation
+)
+
+// String implements the stringer interface for RelationKind
+func (k RelationKind) String() string {
+	switch k {
+	case ContainsRelation:
+		return "contains"
+	case ReturnsRelation:
+		return "returns"
+	case OperatorRelation:
+		return "operator"
+	default:
+		return "unknown"
+	}
+}
+
+// Relation is a directed relationship between two declared types
+type Relation struct {
+	From *Symbol
+	To   *Symbol
+	Kind RelationKind
+	// Label names the field, method or operator the relationship comes from
+	Label string
+}
+
+// Relations lists the relationships between the types declared in docs &
+// the types they reference, in declaration order. Fields relate a type to
+// the types it contains, methods & operators relate a type to the types it
+// produces. References to builtin & unknown types are skipped
+func (t *SymbolTable) Relations(docs Docs) (rels []Relation) {
+	seen := map[Relation]bool{}
+	add := func(from *Symbol, ref *TypeExpr, kind RelationKind, label string) {
+		for _, name := range ref.Names() {
+			to := t.LookupType(name, from.Doc)
+			if to == nil || to.Kind != TypeSymbol {
+				continue
+			}
+			r := Relation{From: from, To: to, Kind: kind, Label: label}
+			if !seen[r] {
+				seen[r] = true
+				rels = append(rels, r)
+			}
+		}
+	}
+
+	for _, doc := range docs.Modules() {
+		for _, typ := range doc.Types {
+			from := t.elements[typ]
+			if from == nil {
+				continue
+			}
+			for _, f := range typ.Fields {
+				add(from, f.TypeExpr(), ContainsRelation, f.Name)
+			}
+			for _, m := range typ.Methods {
+				add(from, m.ReturnTypeExpr(), ReturnsRelation, m.Name()+"()")
+			}
+			for _, o := range typ.Operators {
+				_, _, result := o.TypeExprs()
+				add(from, result, OperatorRelation, o.Expr())
+			}
+		}
+	}
+	return rels
+}
+
+// diagramTypes lists the symbols of types declared in docs, followed by any
+// types declared elsewhere that relations point to
+func (t *SymbolTable) diagramTypes(docs Docs, rels []Relation) (types []*Symbol) {
+	added := map[*Symbol]bool{}
+	add := func(s *Symbol) {
+		if s != nil && !added[s] {
+			added[s] = true
+			types = append(types, s)
+		}
+	}
+	for _, doc := range docs.Modules() {
+		for _, typ := range doc.Types {
+			add(t.elements[typ])
+		}
+	}
+	for _, r := range rels {
+		add(r.To)
+	}
+	return types
+}
+
+// WriteDot writes the types declared in docs & the relationships between
+// them as a Graphviz DOT digraph. Each type is a record node listing its
+// fields & methods
+func (t *SymbolTable) WriteDot(w io.Writer, docs Docs) error {
+	rels := t.Relations(docs)
+	buf := &bytes.Buffer{}
+	buf.WriteString("digraph outline {\n  rankdir=LR;\n  node [shape=record, fontname=\"monospace\"];\n")
+
+	for _, s := range t.diagramTypes(docs, rels) {
+		members := []string{dotEscape(s.Name)}
+		var fields, methods []string
+		for _, f := range s.Type.Fields {
+			fields = append(fields, dotEscape(strings.TrimSpace(f.Name+" "+f.Type))+`\l`)
+		}
+		for _, m := range s.Type.Methods {
+			methods = append(methods, dotEscape(m.Signature)+`\l`)
+		}
+		if len(fields) > 0 || len(methods) > 0 {
+			members = append(members, strings.Join(fields, ""), strings.Join(methods, ""))
+		}
+		fmt.Fprintf(buf, "  %q [label=\"{%s}\"];\n", s.Name, strings.Join(members, "|"))
+	}
+
+	for _, r := range rels {
+		style := ""
+		switch r.Kind {
+		case ContainsRelation:
+			// composition is drawn with a diamond at the containing type
+			style = ", dir=both, arrowtail=diamond"
+		case OperatorRelation:
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(buf, "  %q -> %q [label=%q%s];\n", r.From.Name, r.To.Name, r.Label, style)
+	}
+
+	buf.WriteString("}\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// dotEscape escapes characters that are special within DOT record labels
+func dotEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`)
+	return r.Replace(s)
+}
+
+// WriteMermaid writes the types declared in docs & the relationships between
+// them as a Mermaid class diagram
+func (t *SymbolTable) WriteMermaid(w io.Writer, docs Docs) error {
+	rels := t.Relations(docs)
+	buf := &bytes.Buffer{}
+	buf.WriteString("classDiagram\n")
+
+	for _, s := range t.diagramTypes(docs, rels) {
+		fmt.Fprintf(buf, "  class %s[\"%s\"]", mermaidID(s), s.Name)
+		if len(s.Type.Fields) == 0 && len(s.Type.Methods) == 0 {
+			buf.WriteString("\n")
+			continue
+		}
+		buf.WriteString(" {\n")
+		for _, f := range s.Type.Fields {
+			fmt.Fprintf(buf, "    +%s\n", mermaidMember(strings.TrimSpace(f.Type+" "+f.Name)))
+		}
+		for _, m := range s.Type.Methods {
+			fmt.Fprintf(buf, "    +%s\n", mermaidMember(m.Signature))
+		}
+		buf.WriteString("  }\n")
+	}
+
+	arrows := map[RelationKind]string{
+		ContainsRelation: "*--",
+		ReturnsRelation:  "..>",
+		OperatorRelation: "..>",
+	}
+	for _, r := range rels {
+		fmt.Fprintf(buf, "  %s %s %s : %s\n", mermaidID(r.From), arrows[r.Kind], mermaidID(r.To), mermaidMember(r.Label))
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// mermaidID converts a symbol name to a Mermaid class identifier
+func mermaidID(s *Symbol) string {
+	return strings.Map(func(ch rune) rune {
+		if ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' {
+			return ch
+		}
+		return '_'
+	}, s.Name)
+}
+
+// mermaidMember replaces characters that would end a Mermaid class body or
+// be read as generic type markers within class members & labels
+func mermaidMember(s string) string {
+	return strings.NewReplacer("{", "(", "}", ")", "~", "-").Replace(s)
+}
@@ -0,0 +1,83 @@
+package lib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRelations(t *testing.T) {
+	docs, err := Parse(strings.NewReader(resolveText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _ := docs.Resolve()
+
+	var got []string
+	for _, r := range table.Relations(docs) {
+		got = append(got, r.From.Name+" "+r.Kind.String()+" "+r.To.Name+": "+r.Label)
+	}
+	expect := []string{
+		"geo.point returns geo.polygon: buffer()",
+		"geo.point returns geo.point: travel()",
+		"geo.point operator time.duration: point - point",
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("relations mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestWriteDiagrams(t *testing.T) {
+	docs, err := Parse(strings.NewReader(`
+outline: shapes
+  types:
+    polygon
+      fields:
+        points [point]
+        label string
+    point
+      methods:
+        buffer(r float) polygon`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _ := docs.Resolve()
+
+	dot := &bytes.Buffer{}
+	if err := table.WriteDot(dot, docs); err != nil {
+		t.Fatal(err)
+	}
+	expect := `digraph outline {
+  rankdir=LR;
+  node [shape=record, fontname="monospace"];
+  "shapes.polygon" [label="{shapes.polygon|points [point]\llabel string\l|}"];
+  "shapes.point" [label="{shapes.point||buffer(r float) polygon\l}"];
+  "shapes.polygon" -> "shapes.point" [label="points", dir=both, arrowtail=diamond];
+  "shapes.point" -> "shapes.polygon" [label="buffer()"];
+}
+`
+	if diff := cmp.Diff(expect, dot.String()); diff != "" {
+		t.Errorf("dot mismatch (-want +got):\n%s", diff)
+	}
+
+	mermaid := &bytes.Buffer{}
+	if err := table.WriteMermaid(mermaid, docs); err != nil {
+		t.Fatal(err)
+	}
+	expect = `classDiagram
+  class shapes_polygon["shapes.polygon"] {
+    +[point] points
+    +string label
+  }
+  class shapes_point["shapes.point"] {
+    +buffer(r float) polygon
+  }
+  shapes_polygon *-- shapes_point : points
+  shapes_point ..> shapes_polygon : buffer()
+`
+	if diff := cmp.Diff(expect, mermaid.String()); diff != "" {
+		t.Errorf("mermaid mismatch (-want +got):\n%s", diff)
+	}
+}
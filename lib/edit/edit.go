@@ -0,0 +1,256 @@
+// Package edit changes outline documents in place. Operations return minimal
+// text edits against the source a lib.SyntaxTree was read from, so comments,
+// blank lines & the formatting of untouched lines are kept as written, and
+// outlines embedded in go comments are edited without disturbing the code
+// around them
+package edit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/b5/outline/lib"
+)
+
+// TextEdit replaces the bytes of source text from Start up to End with
+// NewText. Insertions have equal Start & End offsets
+type TextEdit struct {
+	Start, End int
+	NewText    string
+}
+
+// Apply returns a copy of src with edits applied. Edits may be given in any
+// order, but must not overlap
+func Apply(src []byte, edits []TextEdit) ([]byte, error) {
+	sorted := make([]TextEdit, len(edits))
+	copy(sorted, edits)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Start != sorted[j].Start {
+			return sorted[i].Start < sorted[j].Start
+		}
+		return sorted[i].End < sorted[j].End
+	})
+
+	var buf strings.Builder
+	last := 0
+	for _, e := range sorted {
+		if e.Start < last || e.End < e.Start || e.End > len(src) {
+			return nil, fmt.Errorf("edit of offsets %d-%d overlaps another edit or is out of range", e.Start, e.End)
+		}
+		buf.Write(src[last:e.Start])
+		buf.WriteString(e.NewText)
+		last = e.End
+	}
+	buf.Write(src[last:])
+	return []byte(buf.String()), nil
+}
+
+// RenameFunction renames a function or method, along with references to it in
+// the descriptions of tree. name is qualified with the names of the elements
+// that contain it, eg: "geo.point" or "geo.point.buffer"
+func RenameFunction(tree *lib.SyntaxTree, name, newName string) ([]TextEdit, error) {
+	return rename(tree, name, newName, true, "function", "method")
+}
+
+// RenameType renames a type, along with the params, fields, return values &
+// operators of tree that refer to it
+func RenameType(tree *lib.SyntaxTree, name, newName string) ([]TextEdit, error) {
+	return rename(tree, name, newName, true, "type")
+}
+
+// RenameParam renames a param of a function, both in the function's params
+// section & its signature
+func RenameParam(tree *lib.SyntaxTree, name, newName string) ([]TextEdit, error) {
+	return rename(tree, name, newName, true, "param")
+}
+
+// Rename renames the element with a qualified name, along with all references
+// to it. kinds limits the element to a function, method, type, field or param.
+// Without kinds, a name shared by elements of different kinds is an error
+// listing the kinds to choose from. The element
+// doesn't have to be declared in tree: references to elements declared
+// elsewhere are still renamed, making it possible to rename across many files.
+// No edits are returned when tree has neither the element nor references to it
+func Rename(tree *lib.SyntaxTree, name, newName string, kinds ...string) ([]TextEdit, error) {
+	for _, k := range kinds {
+		if !contains(renameKinds, k) {
+			return nil, fmt.Errorf("can't rename elements of kind %q", k)
+		}
+	}
+	return rename(tree, name, newName, false, kinds...)
+}
+
+// renameKinds lists the kinds of element that can be renamed
+var renameKinds = []string{"function", "method", "type", "field", "param"}
+
+func rename(tree *lib.SyntaxTree, name, newName string, declared bool, kinds ...string) ([]TextEdit, error) {
+	if !isName(newName) {
+		return nil, fmt.Errorf("invalid name %q", newName)
+	}
+	found, err := findAll(tree, name, kinds...)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		el         *element
+		candidates []string
+	)
+	for _, f := range found {
+		if el == nil {
+			el = f
+		}
+		if !contains(candidates, f.kind) {
+			candidates = append(candidates, f.kind)
+		}
+	}
+	if len(candidates) > 1 {
+		return nil, fmt.Errorf("%s names more than one kind of element: %s", name, strings.Join(candidates, ", "))
+	}
+	if el == nil && declared {
+		return nil, fmt.Errorf("%s isn't a declared %s", name, strings.Join(kinds, " or "))
+	}
+
+	var edits []TextEdit
+	typ := len(kinds) == 0 || contains(kinds, "type")
+	if el != nil {
+		if el.kind == "module" {
+			return nil, fmt.Errorf("renaming documents isn't supported")
+		}
+		// variadic params are declared after their "*" or "**"
+		at := strings.Index(el.line.Text, el.name)
+		edits = append(edits, replace(el.line, at, at+len(el.name), newName))
+		if el.kind == "param" {
+			edits = append(edits, renameArg(el.owner, el.name, newName)...)
+		}
+		typ = el.kind == "type"
+	}
+
+	// functions often share a name with the type they return, so references
+	// where types are expected are only renamed for types, or elements of
+	// unknown kind declared elsewhere
+	qualifier := name[:strings.LastIndex(name, ".")+1]
+	edits = append(edits, references(tree, name, qualifier+newName, typ, el != nil)...)
+	return edits, nil
+}
+
+// AddParam adds a param to a function or method. param is written as it
+// appears in a params section, eg: "lat float" or "*args". The param is added
+// to the end of the function's params section, which is created if it doesn't
+// exist, and the end of the function's signature
+func AddParam(tree *lib.SyntaxTree, fn, param string) ([]TextEdit, error) {
+	el, err := find(tree, fn)
+	if err != nil {
+		return nil, err
+	}
+	if el == nil || (el.kind != "function" && el.kind != "method") {
+		return nil, fmt.Errorf("%s isn't a declared function", fn)
+	}
+	param = strings.TrimSpace(param)
+	parsed, err := lib.ParseParam(param)
+	if err != nil {
+		return nil, fmt.Errorf("invalid param: %s", err)
+	}
+	name := parsed.Name
+	if len(el.children(name)) > 0 {
+		return nil, fmt.Errorf("%s already has a param named %s", fn, name)
+	}
+
+	var edits []TextEdit
+	if open, close := args(el.line.Text); open != -1 {
+		// signatures list names, keeping the "*" of variadic params
+		arg := param[:strings.Index(param, name)+len(name)]
+		if strings.TrimSpace(el.line.Text[open+1:close]) != "" {
+			arg = ", " + arg
+		}
+		edits = append(edits, replace(el.line, close, close, arg))
+	}
+
+	f := newFile(tree)
+	if sec := section(el.line, lib.ParamsTok); sec != nil {
+		edits = append(edits, f.insertAfter(sec, el.line.Margin, []string{f.childIndent(sec) + param}))
+		return edits, nil
+	}
+	return append(edits, f.insertParams(el.line, []string{param})), nil
+}
+
+// MoveMethod moves a method to the end of the methods of another type,
+// carrying along any comments directly above it. The methods section of the
+// source type is removed if the method was its only member
+func MoveMethod(tree *lib.SyntaxTree, method, typ string) ([]TextEdit, error) {
+	el, err := find(tree, method)
+	if err != nil {
+		return nil, err
+	}
+	if el == nil || el.kind != "method" {
+		return nil, fmt.Errorf("%s isn't a declared method", method)
+	}
+	dst, err := find(tree, typ)
+	if err != nil {
+		return nil, err
+	}
+	if dst == nil || dst.kind != "type" {
+		return nil, fmt.Errorf("%s isn't a declared type", typ)
+	}
+	if dst.line == el.owner {
+		return nil, fmt.Errorf("%s already belongs to %s", method, typ)
+	}
+	if len(dst.children(el.name)) > 0 {
+		return nil, fmt.Errorf("%s already has a method named %s", typ, el.name)
+	}
+
+	f := newFile(tree)
+	start := leadingComments(el.section.Children, indexOf(el.section.Children, el.line))
+	moved := f.span(start, el.line)
+	edits := []TextEdit{f.delete(el.section, start, el.line)}
+
+	if sec := methods(dst.line); sec != nil {
+		lines := reindent(moved, el.line.Indent, f.childIndent(sec))
+		return append(edits, f.insertAfter(sec, dst.line.Margin, lines)), nil
+	}
+	indent := f.childIndent(dst.line)
+	lines := append([]string{indent + "methods:"}, reindent(moved, el.line.Indent, indent+f.unit)...)
+	return append(edits, f.insertAfter(dst.line, dst.line.Margin, lines)), nil
+}
+
+// DeleteField removes a field from a type, along with its description & any
+// comments directly above it. The fields section is removed if the field was
+// its only member
+func DeleteField(tree *lib.SyntaxTree, field string) ([]TextEdit, error) {
+	el, err := find(tree, field)
+	if err != nil {
+		return nil, err
+	}
+	if el == nil || el.kind != "field" {
+		return nil, fmt.Errorf("%s isn't a declared field", field)
+	}
+	f := newFile(tree)
+	start := leadingComments(el.section.Children, indexOf(el.section.Children, el.line))
+	return []TextEdit{f.delete(el.section, start, el.line)}, nil
+}
+
+// isName reports whether s can name an outline element
+func isName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !isIdent(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func isIdent(r rune) bool {
+	return r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9'
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,408 @@
+package edit
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/b5/outline/lib"
+)
+
+const geoText = `outline: geo
+  geo does geography. see ` + "`point()`" + `
+  functions:
+    # makes points
+    point(x, y) point
+      make a point
+      params:
+        x float
+        y float
+  types:
+    point
+      fields:
+        # latitude
+        x float
+        y float
+      methods:
+        buffer(r) polygon
+          returns a polygon, like ` + "`geo.polygon`" + `
+    polygon
+      operators:
+        polygon | polygon = polygon
+      fields:
+        center point
+
+outline: maps
+  functions:
+    pin(p geo.point) geo.point
+`
+
+// run applies the edits of an operation to src, failing the test if the
+// operation returns an error
+func run(t *testing.T, src, filename string, op func(tree *lib.SyntaxTree) ([]TextEdit, error)) string {
+	t.Helper()
+	tree, err := lib.ParseSyntax([]byte(src), lib.Filename(filename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	edits, err := op(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Apply([]byte(src), edits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// edited text must still parse
+	if _, err := lib.Parse(bytes.NewReader(out)); err != nil {
+		t.Errorf("edited text doesn't parse: %s", err)
+	}
+	return string(out)
+}
+
+func check(t *testing.T, expect, got string) {
+	t.Helper()
+	if expect != got {
+		t.Errorf("result mismatch. expected:\n%s\ngot:\n%s", expect, got)
+	}
+}
+
+func TestRenameFunction(t *testing.T) {
+	got := run(t, geoText, "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return RenameFunction(tree, "geo.point", "pt")
+	})
+	expect := `outline: geo
+  geo does geography. see ` + "`pt()`" + `
+  functions:
+    # makes points
+    pt(x, y) point
+      make a point
+      params:
+        x float
+        y float
+  types:
+    point
+      fields:
+        # latitude
+        x float
+        y float
+      methods:
+        buffer(r) polygon
+          returns a polygon, like ` + "`geo.polygon`" + `
+    polygon
+      operators:
+        polygon | polygon = polygon
+      fields:
+        center point
+
+outline: maps
+  functions:
+    pin(p geo.point) geo.point
+`
+	check(t, expect, got)
+
+	tree, _ := lib.ParseSyntax([]byte(geoText))
+	if _, err := RenameFunction(tree, "geo.polygon", "poly"); err == nil {
+		t.Error("expected renaming a type as a function to fail")
+	}
+	if _, err := RenameFunction(tree, "geo.missing", "x"); err == nil {
+		t.Error("expected renaming a missing function to fail")
+	}
+	if _, err := RenameFunction(tree, "geo.point", "not a name"); err == nil {
+		t.Error("expected an invalid name to fail")
+	}
+}
+
+func TestRenameType(t *testing.T) {
+	got := run(t, geoText, "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return RenameType(tree, "geo.polygon", "shape")
+	})
+	expect := `outline: geo
+  geo does geography. see ` + "`point()`" + `
+  functions:
+    # makes points
+    point(x, y) point
+      make a point
+      params:
+        x float
+        y float
+  types:
+    point
+      fields:
+        # latitude
+        x float
+        y float
+      methods:
+        buffer(r) shape
+          returns a polygon, like ` + "`geo.shape`" + `
+    shape
+      operators:
+        shape | shape = shape
+      fields:
+        center point
+
+outline: maps
+  functions:
+    pin(p geo.point) geo.point
+`
+	check(t, expect, got)
+
+	// qualified references in other documents are renamed, the function that
+	// shares the type's name isn't
+	got = run(t, geoText, "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return RenameType(tree, "geo.point", "coord")
+	})
+	expect = `outline: geo
+  geo does geography. see ` + "`point()`" + `
+  functions:
+    # makes points
+    point(x, y) coord
+      make a point
+      params:
+        x float
+        y float
+  types:
+    coord
+      fields:
+        # latitude
+        x float
+        y float
+      methods:
+        buffer(r) polygon
+          returns a polygon, like ` + "`geo.polygon`" + `
+    polygon
+      operators:
+        polygon | polygon = polygon
+      fields:
+        center coord
+
+outline: maps
+  functions:
+    pin(p geo.coord) geo.coord
+`
+	check(t, expect, got)
+
+	// types of signature arguments are renamed, argument names aren't
+	src := `outline: geo
+  functions:
+    distance(point point, b point, *rest [point, line]) float
+  types:
+    point
+`
+	got = run(t, src, "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return RenameType(tree, "geo.point", "coord")
+	})
+	expect = `outline: geo
+  functions:
+    distance(point coord, b coord, *rest [coord, line]) float
+  types:
+    coord
+`
+	check(t, expect, got)
+}
+
+func TestRenameParam(t *testing.T) {
+	got := run(t, geoText, "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return RenameParam(tree, "geo.point.y", "lng")
+	})
+	expect := `outline: geo
+  geo does geography. see ` + "`point()`" + `
+  functions:
+    # makes points
+    point(x, lng) point
+      make a point
+      params:
+        x float
+        lng float
+`
+	check(t, expect, got[:len(expect)])
+
+	src := "outline: fmt\n  functions:\n    printf(format, *args)\n      params:\n        format string = \"%v\"\n        *args any"
+	got = run(t, src, "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return RenameParam(tree, "fmt.printf.args", "values")
+	})
+	expect = "outline: fmt\n  functions:\n    printf(format, *values)\n      params:\n        format string = \"%v\"\n        *values any"
+	check(t, expect, got)
+}
+
+func TestRename(t *testing.T) {
+	tree, err := lib.ParseSyntax([]byte(geoText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := "geo.point names more than one kind of element: function, type"
+	if _, err := Rename(tree, "geo.point", "coord"); err == nil || err.Error() != expect {
+		t.Errorf("expected error %q, got: %v", expect, err)
+	}
+
+	got := run(t, geoText, "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return Rename(tree, "geo.point", "coord", "type")
+	})
+	if !strings.Contains(got, "    point(x, y) coord\n") || !strings.Contains(got, "    coord\n      fields:") {
+		t.Errorf("expected the point type to be renamed, got:\n%s", got)
+	}
+}
+
+func TestAddParam(t *testing.T) {
+	src := `outline: geo
+  functions:
+    point(x) point
+      params:
+        x float
+    origin() point
+      the origin
+      examples:
+        zero
+          code:
+            origin()
+    center(a) point`
+
+	got := run(t, src, "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		var edits []TextEdit
+		for _, add := range [][2]string{
+			{"geo.point", "y float"},
+			{"geo.origin", "srid int"},
+			{"geo.center", "b polygon"},
+		} {
+			e, err := AddParam(tree, add[0], add[1])
+			if err != nil {
+				return nil, err
+			}
+			edits = append(edits, e...)
+		}
+		return edits, nil
+	})
+	expect := `outline: geo
+  functions:
+    point(x, y) point
+      params:
+        x float
+        y float
+    origin(srid) point
+      the origin
+      params:
+        srid int
+      examples:
+        zero
+          code:
+            origin()
+    center(a, b) point
+      params:
+        b polygon`
+	check(t, expect, got)
+
+	tree, _ := lib.ParseSyntax([]byte(src))
+	if _, err := AddParam(tree, "geo.point", "x int"); err == nil {
+		t.Error("expected adding a duplicate param to fail")
+	}
+	got = run(t, "outline: geo\n  functions:\n    center(a) point", "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return AddParam(tree, "geo.center", "*rest polygon")
+	})
+	check(t, "outline: geo\n  functions:\n    center(a, *rest) point\n      params:\n        *rest polygon", got)
+	if _, err := AddParam(tree, "geo.point", "z list[int"); err == nil {
+		t.Error("expected adding a malformed param to fail")
+	}
+}
+
+func TestMoveMethod(t *testing.T) {
+	src := `outline: geo
+  types:
+    point
+      methods:
+        # buffers
+        buffer(r) polygon
+          makes a polygon
+    polygon
+      fields:
+        center point
+    line
+      methods:
+        length() float
+`
+	got := run(t, src, "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return MoveMethod(tree, "geo.point.buffer", "geo.polygon")
+	})
+	expect := `outline: geo
+  types:
+    point
+    polygon
+      fields:
+        center point
+      methods:
+        # buffers
+        buffer(r) polygon
+          makes a polygon
+    line
+      methods:
+        length() float
+`
+	check(t, expect, got)
+
+	got = run(t, src, "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return MoveMethod(tree, "geo.point.buffer", "geo.line")
+	})
+	expect = `outline: geo
+  types:
+    point
+    polygon
+      fields:
+        center point
+    line
+      methods:
+        length() float
+        # buffers
+        buffer(r) polygon
+          makes a polygon
+`
+	check(t, expect, got)
+}
+
+func TestDeleteFieldGoSource(t *testing.T) {
+	src := `package geo
+
+// outline: geo
+//	types:
+//		point
+//			fields:
+//				x float
+//				// latitude
+//				y float
+//					the y coordinate
+func init() {}
+`
+	got := run(t, src, "geo.go", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return DeleteField(tree, "geo.point.y")
+	})
+	expect := `package geo
+
+// outline: geo
+//	types:
+//		point
+//			fields:
+//				x float
+func init() {}
+`
+	check(t, expect, got)
+
+	// removing the last field removes the section, & the line ending before it
+	// at the end of input
+	src = "outline: geo\n  types:\n    point\n      description\n      fields:\n        x float"
+	got = run(t, src, "", func(tree *lib.SyntaxTree) ([]TextEdit, error) {
+		return DeleteField(tree, "geo.point.x")
+	})
+	check(t, "outline: geo\n  types:\n    point\n      description", got)
+}
+
+func TestApply(t *testing.T) {
+	src := []byte("abcdef")
+	got, err := Apply(src, []TextEdit{{Start: 4, End: 6, NewText: "x"}, {Start: 0, End: 0, NewText: ">"}, {Start: 1, End: 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(t, ">acdx", string(got))
+
+	if _, err := Apply(src, []TextEdit{{Start: 0, End: 3}, {Start: 2, End: 4}}); err == nil {
+		t.Error("expected overlapping edits to fail")
+	}
+}
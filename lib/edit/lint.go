@@ -0,0 +1,351 @@
+package edit
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/b5/outline/lib"
+)
+
+// Fix is a problem found in an outline document, along with the edits that
+// repair it
+type Fix struct {
+	lib.Diagnostic
+	Edits []TextEdit
+}
+
+// Lint finds problems in the documents of tree that can be repaired
+// automatically:
+//
+//	misspelled keywords, like "parms:"
+//	mixed tabs & spaces in indentation
+//	functions with arguments in their signature & no params section
+//	params that aren't in the signature of their function
+//
+// opts configure parsing, so custom sections added with lib.Sections aren't
+// mistaken for misspelled keywords. Fixes are ordered by position
+func Lint(tree *lib.SyntaxTree, opts ...lib.Option) ([]Fix, error) {
+	f := newFile(tree)
+	// sections are compared to signatures once their keywords are fixed
+	pending := map[*lib.SyntaxNode]bool{}
+	fixes, err := keywordFixes(tree, f, opts, pending)
+	if err != nil {
+		return nil, err
+	}
+	fixes = append(fixes, indentFixes(tree)...)
+	fixes = append(fixes, paramFixes(tree, f, pending)...)
+	sort.SliceStable(fixes, func(i, j int) bool {
+		return fixes[i].Pos.Offset < fixes[j].Pos.Offset
+	})
+	return fixes, nil
+}
+
+// ApplyFixes applies the edits of fixes to src. Fixes with edits that overlap
+// those of an earlier fix are skipped & returned, linting the fixed source
+// finds them again
+func ApplyFixes(src []byte, fixes []Fix) (fixed []byte, skipped []Fix, err error) {
+	var edits []TextEdit
+	for _, fix := range fixes {
+		if overlaps(edits, fix.Edits) {
+			skipped = append(skipped, fix)
+			continue
+		}
+		edits = append(edits, fix.Edits...)
+	}
+	fixed, err = Apply(src, edits)
+	return fixed, skipped, err
+}
+
+// overlaps reports whether any edit of b touches the text of an edit of a.
+// Insertions at the same offset overlap, as their order would be ambiguous
+func overlaps(a, b []TextEdit) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if (x.Start < y.End && y.Start < x.End) || x.Start == y.Start {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keywordFixes replaces misspelled keywords with the keyword the parser
+// suggests, marking fixed lines as pending
+func keywordFixes(tree *lib.SyntaxTree, f *file, opts []lib.Option, pending map[*lib.SyntaxNode]bool) (fixes []Fix, err error) {
+	_, err = tree.Docs(append(append([]lib.Option{}, opts...), lib.Strict())...)
+	diags, ok := err.(lib.Diagnostics)
+	if err != nil && !ok {
+		return nil, err
+	}
+
+	headers := sectionHeaders(tree)
+	lines := map[int]*lib.SyntaxNode{}
+	for _, n := range f.lines {
+		lines[n.Pos.Line] = n
+	}
+	for _, d := range diags {
+		n := lines[d.Pos.Line]
+		if d.Suggestion == "" || n == nil || !headers[n] {
+			continue
+		}
+		// parsed columns count from the start of the line after its margin
+		at := d.Pos.Col - 1 - len(n.Indent)
+		if at < 0 || at >= len(n.Text) {
+			continue
+		}
+		end := strings.IndexAny(n.Text[at:], " \t")
+		if end == -1 {
+			end = len(n.Text) - at
+		}
+		// fixes are positioned in the source, which includes margins
+		d.Pos.Offset = n.Pos.Offset + len(n.Margin) + d.Pos.Col - 1
+		pending[n] = true
+		fixes = append(fixes, Fix{
+			Diagnostic: d,
+			Edits:      []TextEdit{replace(n, at, at+end, d.Suggestion)},
+		})
+	}
+	return fixes, nil
+}
+
+// sectionHeaders finds the lines of tree written where a section keyword can
+// be: single words ending in ":" nested beneath a document or element, before
+// any description text. Lines like "Returns:" within descriptions aren't
+// section headers
+func sectionHeaders(tree *lib.SyntaxTree) map[*lib.SyntaxNode]bool {
+	headers := map[*lib.SyntaxNode]bool{}
+	var element, section func(n *lib.SyntaxNode)
+	// element visits the lines of a document or element, which begin with
+	// sections, followed by description text & sections
+	element = func(n *lib.SyntaxNode) {
+		desc := false
+		for _, c := range n.Children {
+			switch {
+			case c.Kind == lib.KeywordLine && c.Keyword == lib.DocumentTok:
+				element(c)
+			case c.Kind == lib.KeywordLine:
+				section(c)
+			case c.Kind != lib.TextLine:
+			case !desc && isHeader(c.Text):
+				headers[c] = true
+				section(c)
+			default:
+				desc = true
+			}
+		}
+	}
+	// section visits the elements of a section
+	section = func(n *lib.SyntaxNode) {
+		for _, c := range n.Children {
+			if c.Kind == lib.TextLine {
+				element(c)
+			}
+		}
+	}
+
+	for _, n := range tree.Nodes {
+		if n.Kind == lib.KeywordLine && n.Keyword == lib.DocumentTok {
+			element(n)
+		}
+	}
+	return headers
+}
+
+// isHeader reports whether text is a single word followed by a colon
+func isHeader(text string) bool {
+	word := strings.TrimSuffix(strings.TrimSpace(text), ":")
+	if word == "" || len(word) == len(strings.TrimSpace(text)) {
+		return false
+	}
+	for _, r := range word {
+		if !(r == '_' || unicode.IsLetter(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// indentFixes re-indents documents that mix tabs & spaces, indenting each
+// level the way the document's first indented line does
+func indentFixes(tree *lib.SyntaxTree) (fixes []Fix) {
+	for _, doc := range tree.Nodes {
+		if doc.Kind != lib.KeywordLine || doc.Keyword != lib.DocumentTok {
+			continue
+		}
+
+		var (
+			unit         string
+			tabs, spaces bool
+			mixed        *lib.SyntaxNode
+		)
+		var visit func(n *lib.SyntaxNode)
+		visit = func(n *lib.SyntaxNode) {
+			for _, c := range n.Children {
+				if mixed != nil || (c.Kind != lib.KeywordLine && c.Kind != lib.TextLine) {
+					continue
+				}
+				if c.Keyword != lib.DocumentTok {
+					rel := strings.TrimPrefix(c.Indent, doc.Indent)
+					if unit == "" && rel != "" {
+						unit = rel
+						if strings.Contains(rel, "\t") {
+							unit = "\t"
+						}
+					}
+					tabs = tabs || strings.Count(c.Indent, "\t") > strings.Count(doc.Indent, "\t")
+					spaces = spaces || strings.Count(c.Indent, " ") > strings.Count(doc.Indent, " ")
+					if tabs && spaces {
+						mixed = c
+						return
+					}
+				}
+				visit(c)
+			}
+		}
+		visit(doc)
+		if mixed == nil {
+			continue
+		}
+
+		fixes = append(fixes, Fix{
+			Diagnostic: lib.Diagnostic{
+				Pos: lib.Position{
+					Filename: mixed.Pos.Filename,
+					Line:     mixed.Pos.Line,
+					Col:      1,
+					Offset:   mixed.Pos.Offset + len(mixed.Margin),
+				},
+				Severity: lib.Warning,
+				Message:  "mixed tabs and spaces in indentation",
+			},
+			Edits: reindentDoc(tree, doc, unit),
+		})
+	}
+	return fixes
+}
+
+// reindentDoc formats a copy of tree, returning edits that change the
+// indentation of each line of doc to match the copy
+func reindentDoc(tree *lib.SyntaxTree, doc *lib.SyntaxNode, unit string) (edits []TextEdit) {
+	formatted, err := lib.ParseSyntax(tree.Bytes(), lib.Filename(tree.Filename))
+	if err != nil {
+		return nil
+	}
+	formatted.Format(unit)
+
+	var lines []*lib.SyntaxNode
+	formatted.Inspect(func(n *lib.SyntaxNode, depth int) bool {
+		lines = append(lines, n)
+		return true
+	})
+
+	i, in := 0, false
+	tree.Inspect(func(n *lib.SyntaxNode, depth int) bool {
+		if depth == 0 {
+			in = n == doc
+		}
+		if in && n.Kind != lib.BlankLine && n.Indent != lines[i].Indent {
+			start := n.Pos.Offset + len(n.Margin)
+			edits = append(edits, TextEdit{Start: start, End: start + len(n.Indent), NewText: lines[i].Indent})
+		}
+		i++
+		return true
+	})
+	return edits
+}
+
+// paramFixes compares the params sections of functions to the arguments of
+// their signatures, adding missing sections & removing params that aren't
+// arguments
+func paramFixes(tree *lib.SyntaxTree, f *file, pending map[*lib.SyntaxNode]bool) (fixes []Fix) {
+	walk(tree, func(el *element, name string) {
+		if el.kind != "function" && el.kind != "method" {
+			return
+		}
+		for _, c := range el.line.Children {
+			if pending[c] {
+				return
+			}
+		}
+		if open, _ := args(el.line.Text); open == -1 {
+			return
+		}
+		var argNames, params []string
+		for _, arg := range sigArgs(el.line.Text) {
+			if arg.from == arg.to {
+				// markers like a lone "*" aren't arguments
+				continue
+			}
+			argNames = append(argNames, el.line.Text[arg.from:arg.to])
+			text := strings.TrimSpace(el.line.Text[arg.start:arg.end])
+			if p, err := lib.ParseParam(text); err == nil {
+				params = append(params, p.Decl())
+			} else {
+				params = append(params, strings.TrimSpace(el.line.Text[arg.start:arg.to]))
+			}
+		}
+
+		sec := section(el.line, lib.ParamsTok)
+		if sec == nil {
+			if len(params) > 0 {
+				fixes = append(fixes, Fix{
+					Diagnostic: warning(el.line, "%s has no params: section for the arguments in its signature", name),
+					Edits:      []TextEdit{f.insertParams(el.line, params)},
+				})
+			}
+			return
+		}
+
+		var declared, stale []*element
+		el.each(func(c *element) {
+			if c.kind == "param" {
+				declared = append(declared, c)
+				if !contains(argNames, c.name) {
+					stale = append(stale, c)
+				}
+			}
+		})
+		if len(stale) == 0 {
+			return
+		}
+
+		var edits []TextEdit
+		if len(stale) == len(declared) {
+			edits = append(edits, f.delete(sec, sec.Children[0], stale[len(stale)-1].line))
+		} else {
+			for _, p := range stale {
+				start := leadingComments(sec.Children, indexOf(sec.Children, p.line))
+				edits = append(edits, f.delete(sec, start, p.line))
+			}
+		}
+		names := make([]string, len(stale))
+		for i, p := range stale {
+			names[i] = p.name
+		}
+		msg := "param %s isn't in the signature of %s"
+		if len(stale) > 1 {
+			msg = "params %s aren't in the signature of %s"
+		}
+		fixes = append(fixes, Fix{
+			Diagnostic: warning(stale[0].line, msg, strings.Join(names, ", "), name),
+			Edits:      edits,
+		})
+	})
+	return fixes
+}
+
+// warning creates a warning diagnostic positioned at the text of a line
+func warning(n *lib.SyntaxNode, format string, args ...interface{}) lib.Diagnostic {
+	return lib.Diagnostic{
+		Pos: lib.Position{
+			Filename: n.Pos.Filename,
+			Line:     n.Pos.Line,
+			Col:      len(n.Indent) + 1,
+			Offset:   n.Pos.Offset + len(n.Margin) + len(n.Indent),
+		},
+		Severity: lib.Warning,
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
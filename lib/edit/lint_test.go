@@ -0,0 +1,204 @@
+package edit
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/b5/outline/lib"
+	"github.com/google/go-cmp/cmp"
+)
+
+// lint fixes src until no fixes are left, returning the fixed text & the
+// diagnostics of the first pass
+func lint(t *testing.T, src, filename string, opts ...lib.Option) (string, []string) {
+	t.Helper()
+	var msgs []string
+	for i := 0; i < 5; i++ {
+		tree, err := lib.ParseSyntax([]byte(src), lib.Filename(filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fixes, err := Lint(tree, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fixes) == 0 {
+			return src, msgs
+		}
+		if i == 0 {
+			for _, fix := range fixes {
+				msgs = append(msgs, fix.String())
+			}
+		}
+		fixed, _, err := ApplyFixes([]byte(src), fixes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		src = string(fixed)
+	}
+	t.Fatalf("fixes didn't settle, last result:\n%s", src)
+	return "", nil
+}
+
+func TestLint(t *testing.T) {
+	src := `outline: geo
+  functions:
+    point(lat, lng float) point
+      make a point
+      examples:
+        origin
+    within(a, b, *rest)
+      parms:
+        a geom
+        b geom
+        *rest geom
+    area(geom) float
+      # the shape
+      params:
+        geom polygon
+        # left over from an old signature
+        units string = "m"
+    len()
+      params:
+        x int
+`
+	got, msgs := lint(t, src, "")
+	expect := `outline: geo
+  functions:
+    point(lat, lng float) point
+      make a point
+      params:
+        lat
+        lng float
+      examples:
+        origin
+    within(a, b, *rest)
+      params:
+        a geom
+        b geom
+        *rest geom
+    area(geom) float
+      # the shape
+      params:
+        geom polygon
+    len()
+`
+	check(t, expect, got)
+
+	expectMsgs := []string{
+		"3:5: warning: geo.point has no params: section for the arguments in its signature",
+		`8:7: error: unknown keyword "parms:", did you mean "params:"?`,
+		"17:9: warning: param units isn't in the signature of geo.area",
+		"20:9: warning: param x isn't in the signature of geo.len",
+	}
+	if diff := cmp.Diff(expectMsgs, msgs); diff != "" {
+		t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLintIndentation(t *testing.T) {
+	src := "outline: geo\n\tfunctions:\n\t\tpoint(x)\n\t\t    make a point\n\t\t\tparams:\n\t\t\t\tx float\n\t\t\texamples:\n\t\t\t\tzero\n\t\t\t\t\tcode:\n\t\t\t\t\t\tpoint(0)\n"
+	got, msgs := lint(t, src, "")
+	expect := "outline: geo\n\tfunctions:\n\t\tpoint(x)\n\t\t\tmake a point\n\t\t\tparams:\n\t\t\t\tx float\n\t\t\texamples:\n\t\t\t\tzero\n\t\t\t\t\tcode:\n\t\t\t\t\t\tpoint(0)\n"
+	check(t, expect, got)
+	if diff := cmp.Diff([]string{"4:1: warning: mixed tabs and spaces in indentation"}, msgs); diff != "" {
+		t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestLintGoSource(t *testing.T) {
+	src := `package geo
+
+// outline: geo
+//   functions:
+//     point(x, y)
+//       parms:
+//         x float
+//         z float
+func init() {}
+`
+	got, _ := lint(t, src, "geo.go")
+	expect := `package geo
+
+// outline: geo
+//   functions:
+//     point(x, y)
+//       params:
+//         x float
+func init() {}
+`
+	check(t, expect, got)
+}
+
+func TestLintGoStrings(t *testing.T) {
+	// outlines in go strings aren't linted or fixed
+	src := "package geo\n\nconst fixture = `\n/*\noutline: geo\n  functions:\n    point(x)\n\t\tmake a point\n      parms:\n        y int\n`\n"
+	if got, msgs := lint(t, src, "geo.go"); got != src || len(msgs) > 0 {
+		t.Errorf("expected go strings to be left alone, got:\n%s\n%v", got, msgs)
+	}
+}
+
+func TestLintSections(t *testing.T) {
+	src := "outline: fs\n  functions:\n    open()\n      permisions:\n        read: all\n"
+	sections := lib.Sections(&lib.Section{Name: "permisions", Kind: lib.KeyValueSection, In: lib.InFunction})
+	if got, msgs := lint(t, src, "", sections); got != src || len(msgs) > 0 {
+		t.Errorf("expected custom sections not to be fixed, got:\n%s\n%v", got, msgs)
+	}
+}
+
+func TestLintDescriptions(t *testing.T) {
+	src := `outline: geo
+  geo does geography
+  Example:
+    geo.point(1, 2)
+  functions:
+    point(x float) point
+      make a point
+      Returns:
+        a point
+      params:
+        x float
+    area(geom)
+      exmples:
+        square
+      params:
+        geom polygon
+`
+	got, msgs := lint(t, src, "")
+	expect := strings.Replace(src, "exmples:", "examples:", 1)
+	check(t, expect, got)
+	if diff := cmp.Diff([]string{`13:7: error: unknown keyword "exmples:", did you mean "examples:"?`}, msgs); diff != "" {
+		t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+	}
+
+	tree, err := lib.ParseSyntax([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var headers []string
+	for n := range sectionHeaders(tree) {
+		headers = append(headers, n.Text)
+	}
+	if diff := cmp.Diff([]string{"exmples:"}, headers); diff != "" {
+		t.Errorf("section headers mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestApplyFixes(t *testing.T) {
+	src := []byte("abcdef")
+	fixes := []Fix{
+		{Edits: []TextEdit{{Start: 0, End: 2, NewText: "AB"}}},
+		{Edits: []TextEdit{{Start: 1, End: 3, NewText: "x"}}},
+		{Edits: []TextEdit{{Start: 4, End: 4, NewText: "-"}}},
+	}
+	got, skipped, err := ApplyFixes(src, fixes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "ABcd-ef" {
+		t.Errorf("unexpected result: %q", got)
+	}
+	if len(skipped) != 1 || skipped[0].Edits[0].NewText != "x" {
+		t.Errorf("expected the overlapping fix to be skipped, got: %v", skipped)
+	}
+}
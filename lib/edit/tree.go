@@ -0,0 +1,543 @@
+package edit
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/b5/outline/lib"
+)
+
+// element is a declaration found in a syntax tree
+type element struct {
+	// line declares the element
+	line *lib.SyntaxNode
+	// section is the keyword line of the section that lists the element, &
+	// owner the line of the element the section belongs to. both are nil for
+	// top-level documents, & section is nil for submodules written as nested
+	// documents
+	section, owner *lib.SyntaxNode
+	// kind is one of module, function, method, type, field or param
+	kind string
+	name string
+}
+
+// childKinds maps the kind of an element to the kinds of elements each of its
+// sections declare
+var childKinds = map[string]map[lib.TokenType]string{
+	"module": {
+		lib.FunctionsTok: "function",
+		lib.TypesTok:     "type",
+		lib.ModulesTok:   "module",
+	},
+	"type": {
+		lib.MethodsTok:   "method",
+		lib.FunctionsTok: "method",
+		lib.FieldsTok:    "field",
+	},
+	"function": {lib.ParamsTok: "param"},
+	"method":   {lib.ParamsTok: "param"},
+}
+
+// find looks up an element by qualified name, eg: "geo.point.buffer". Names
+// can be shared by elements of different kinds, like a type & the function
+// that constructs it; only elements of the listed kinds are found when kinds
+// are given. find returns nil if tree doesn't declare the element
+func find(tree *lib.SyntaxTree, name string, kinds ...string) (*element, error) {
+	found, err := findAll(tree, name, kinds...)
+	if err != nil || len(found) == 0 {
+		return nil, err
+	}
+	return found[0], nil
+}
+
+// findAll lists every element declared with a qualified name, in the order
+// they're declared
+func findAll(tree *lib.SyntaxTree, name string, kinds ...string) ([]*element, error) {
+	parts := strings.Split(name, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("%q isn't a qualified name, eg: geo.point", name)
+	}
+	for _, p := range parts {
+		if !isName(p) {
+			return nil, fmt.Errorf("invalid name %q", name)
+		}
+	}
+
+	var (
+		found  []*element
+		search func(el *element, parts []string)
+	)
+	search = func(el *element, parts []string) {
+		if len(parts) == 0 {
+			if len(kinds) == 0 || contains(kinds, el.kind) {
+				found = append(found, el)
+			}
+			return
+		}
+		for _, c := range el.children(parts[0]) {
+			search(c, parts[1:])
+		}
+	}
+
+	for _, n := range tree.Nodes {
+		if n.Kind == lib.KeywordLine && n.Keyword == lib.DocumentTok && n.Value() == parts[0] {
+			search(&element{line: n, kind: "module", name: parts[0]}, parts[1:])
+		}
+	}
+	return found, nil
+}
+
+// children finds the elements declared within el with a name
+func (el *element) children(name string) (found []*element) {
+	el.each(func(c *element) {
+		if c.name == name {
+			found = append(found, c)
+		}
+	})
+	return found
+}
+
+// each calls f for every element declared directly within el
+func (el *element) each(f func(c *element)) {
+	for _, sec := range el.line.Children {
+		if el.kind == "module" && sec.Kind == lib.KeywordLine && sec.Keyword == lib.DocumentTok {
+			// submodules written as nested documents
+			f(&element{line: sec, owner: el.line, kind: "module", name: sec.Value()})
+			continue
+		}
+		kind, ok := childKinds[el.kind][sec.Keyword]
+		if sec.Kind != lib.KeywordLine || !ok {
+			continue
+		}
+		for _, n := range sec.Children {
+			if n.Kind == lib.TextLine {
+				f(&element{line: n, section: sec, owner: el.line, kind: kind, name: declName(sec.Keyword, n.Text)})
+			}
+		}
+	}
+}
+
+// walk calls f for every element of tree, along with its qualified name
+func walk(tree *lib.SyntaxTree, f func(el *element, name string)) {
+	var visit func(el *element, name string)
+	visit = func(el *element, name string) {
+		f(el, name)
+		el.each(func(c *element) { visit(c, name+"."+c.name) })
+	}
+	for _, n := range tree.Nodes {
+		if n.Kind == lib.KeywordLine && n.Keyword == lib.DocumentTok {
+			visit(&element{line: n, kind: "module", name: n.Value()}, n.Value())
+		}
+	}
+}
+
+// declName reads the name of an element from the line that declares it
+func declName(section lib.TokenType, text string) string {
+	switch section {
+	case lib.FunctionsTok, lib.MethodsTok:
+		if i := strings.Index(text, "("); i != -1 {
+			return strings.TrimSpace(text[:i])
+		}
+	case lib.ParamsTok:
+		if param, err := lib.ParseParam(text); err == nil {
+			return param.Name
+		}
+		if fields := strings.Fields(strings.TrimLeft(text, "*")); len(fields) > 0 {
+			return strings.TrimSuffix(fields[0], "?")
+		}
+	case lib.FieldsTok:
+		if fields := strings.Fields(text); len(fields) > 0 {
+			return fields[0]
+		}
+	case lib.TypesTok:
+		// generic types are followed by their type parameters: "box[T]"
+		if i := strings.Index(text, "["); i != -1 {
+			return strings.TrimSpace(text[:i])
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// section returns the child keyword line of n for a section, if any
+func section(n *lib.SyntaxNode, kw lib.TokenType) *lib.SyntaxNode {
+	for _, c := range n.Children {
+		if c.Kind == lib.KeywordLine && c.Keyword == kw {
+			return c
+		}
+	}
+	return nil
+}
+
+// methods returns the methods section of a type, which may be written as
+// "methods:" or "functions:"
+func methods(typ *lib.SyntaxNode) *lib.SyntaxNode {
+	if sec := section(typ, lib.MethodsTok); sec != nil {
+		return sec
+	}
+	return section(typ, lib.FunctionsTok)
+}
+
+// args finds the parentheses that enclose the arguments of a signature.
+// open is -1 if the signature doesn't have arguments
+func args(sig string) (open, close int) {
+	open = strings.Index(sig, "(")
+	if open == -1 {
+		return -1, -1
+	}
+	depth := 0
+	for i := open; i < len(sig); i++ {
+		switch sig[i] {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth == 0 {
+				return open, i
+			}
+		}
+	}
+	return -1, -1
+}
+
+// renameArg renames an argument in the signature of a function line
+func renameArg(fn *lib.SyntaxNode, name, newName string) (edits []TextEdit) {
+	for _, arg := range sigArgs(fn.Text) {
+		if fn.Text[arg.from:arg.to] == name {
+			edits = append(edits, replace(fn, arg.from, arg.to, newName))
+		}
+	}
+	return edits
+}
+
+// sigArg is an argument of a function signature. start & end are the offsets
+// of the argument's text within the signature, from & to those of its name
+type sigArg struct {
+	start, end, from, to int
+}
+
+// sigArgs splits the arguments of a function signature, returning nil if the
+// signature has no argument list
+func sigArgs(sig string) (found []sigArg) {
+	open, close := args(sig)
+	if open == -1 {
+		return nil
+	}
+	depth, start := 0, open+1
+	for i := open + 1; i <= close; i++ {
+		switch c := sig[i]; {
+		case c == '(' || c == '[' || c == '{':
+			depth++
+		case (c == ')' || c == ']' || c == '}') && i != close:
+			depth--
+		case (c == ',' && depth == 0) || i == close:
+			// arguments may be written with a type or default after the name, or
+			// a leading "*" for variadic args
+			j := start
+			for j < i && (sig[j] == ' ' || sig[j] == '*') {
+				j++
+			}
+			k := j
+			for k < i && isIdent(rune(sig[k])) {
+				k++
+			}
+			if strings.TrimSpace(sig[start:i]) != "" {
+				found = append(found, sigArg{start: start, end: i, from: j, to: k})
+			}
+			start = i + 1
+		}
+	}
+	return found
+}
+
+// references renames uses of a qualified name within tree. Any element can be
+// referenced from a code span in a description, eg: `geo.point` or
+// `geo.point()` for functions, while types are also referenced from params, fields, return values & operators when typ
+// is true. bare allows unqualified references within the document declaring
+// the element
+func references(tree *lib.SyntaxTree, name, newName string, typ, bare bool) (edits []TextEdit) {
+	parts := strings.Split(name, ".")
+	short, newShort := parts[len(parts)-1], newName[strings.LastIndex(newName, ".")+1:]
+
+	var visit func(nodes []*lib.SyntaxNode, parent *lib.SyntaxNode, local bool)
+	visit = func(nodes []*lib.SyntaxNode, parent *lib.SyntaxNode, local bool) {
+		for _, n := range nodes {
+			// from is the offset type references begin at, & bareFrom the offset
+			// unqualified references begin at. sig holds the arguments of a
+			// signature, which may have types after their names
+			from, bareFrom := -1, -1
+			var sig []sigArg
+			switch {
+			case n.Kind == lib.KeywordLine && n.Keyword == lib.ReturnTok:
+				from = len("return:")
+			case n.Kind != lib.TextLine:
+			case parent.Keyword == lib.FunctionsTok || parent.Keyword == lib.MethodsTok:
+				// argument names are never qualified, so qualified references can
+				// be found among them
+				if open, close := args(n.Text); open != -1 {
+					from, bareFrom = open+1, close+1
+					sig = sigArgs(n.Text)
+				}
+			case parent.Keyword == lib.ParamsTok || parent.Keyword == lib.FieldsTok:
+				if i := strings.IndexAny(n.Text, " \t"); i != -1 {
+					from = i
+				}
+			case parent.Keyword == lib.ReturnTok || parent.Keyword == lib.OperatorsTok:
+				from = 0
+			case parent.Keyword == lib.TypesTok || parent.Keyword == lib.ExamplesTok:
+			default:
+				// descriptions
+				local := bare && local && len(parts) == 2
+				for _, span := range codeSpans(n.Text) {
+					text := n.Text[span[0]:span[1]]
+					if strings.HasSuffix(text, "()") {
+						if typ && bare {
+							// calls don't refer to types
+							continue
+						}
+						text = strings.TrimSuffix(text, "()")
+					}
+					switch {
+					case text == name:
+						edits = append(edits, replace(n, span[0], span[0]+len(name), newName))
+					case local && text == short:
+						edits = append(edits, replace(n, span[0], span[0]+len(short), newShort))
+					}
+				}
+			}
+
+			if typ && from != -1 {
+				for _, i := range words(n.Text, name, from) {
+					edits = append(edits, replace(n, i, i+len(name), newName))
+				}
+				if bareFrom == -1 {
+					bareFrom = from
+				}
+				if bare && local {
+					for _, i := range words(n.Text, short, bareFrom) {
+						edits = append(edits, replace(n, i, i+len(short), newShort))
+					}
+					for _, arg := range sig {
+						for _, i := range words(n.Text[:arg.end], short, arg.to) {
+							edits = append(edits, replace(n, i, i+len(short), newShort))
+						}
+					}
+				}
+			}
+			visit(n.Children, n, local)
+		}
+	}
+
+	for _, n := range tree.Nodes {
+		if n.Kind == lib.KeywordLine && n.Keyword == lib.DocumentTok {
+			visit(n.Children, n, n.Value() == parts[0])
+		}
+	}
+	return edits
+}
+
+// words finds whole-word uses of name in text at or after offset from.
+// words joined to name by a dot are part of a different name
+func words(text, name string, from int) (found []int) {
+	for i := from; i < len(text); {
+		j := strings.Index(text[i:], name)
+		if j == -1 {
+			break
+		}
+		start, end := i+j, i+j+len(name)
+		if (start == 0 || !isWordByte(text[start-1])) && (end == len(text) || !isWordByte(text[end])) {
+			found = append(found, start)
+		}
+		i = end
+	}
+	return found
+}
+
+func isWordByte(b byte) bool {
+	return b == '.' || isIdent(rune(b))
+}
+
+// codeSpans returns the start & end offsets of the content of each
+// backtick-delimited code span in text
+func codeSpans(text string) (spans [][2]int) {
+	for i := 0; i < len(text); i++ {
+		if text[i] != '`' {
+			continue
+		}
+		end := strings.IndexByte(text[i+1:], '`')
+		if end == -1 {
+			break
+		}
+		spans = append(spans, [2]int{i + 1, i + 1 + end})
+		i += end + 1
+	}
+	return spans
+}
+
+// replace edits the text of a line from offset start up to end
+func replace(n *lib.SyntaxNode, start, end int, text string) TextEdit {
+	offset := n.Pos.Offset + len(n.Margin) + len(n.Indent)
+	return TextEdit{Start: offset + start, End: offset + end, NewText: text}
+}
+
+// leadingComments returns the first of the comment lines directly above the
+// i'th line in a list, or the i'th line itself if there are none
+func leadingComments(lines []*lib.SyntaxNode, i int) *lib.SyntaxNode {
+	for i > 0 && lines[i-1].Kind == lib.CommentLine {
+		i--
+	}
+	return lines[i]
+}
+
+func indexOf(lines []*lib.SyntaxNode, n *lib.SyntaxNode) int {
+	for i, l := range lines {
+		if l == n {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastLine returns the last line nested within n, or n itself
+func lastLine(n *lib.SyntaxNode) *lib.SyntaxNode {
+	for len(n.Children) > 0 {
+		n = n.Children[len(n.Children)-1]
+	}
+	return n
+}
+
+// lineEnd returns the offset of the byte after a line, including its line
+// ending
+func lineEnd(n *lib.SyntaxNode) int {
+	return n.Pos.Offset + len(n.Margin) + len(n.Indent) + len(n.Text) + len(n.EOL)
+}
+
+// reindent writes lines with their indentation changed from one base to
+// another, keeping relative indentation
+func reindent(lines []*lib.SyntaxNode, from, to string) (text []string) {
+	for _, n := range lines {
+		if n.Kind == lib.BlankLine {
+			text = append(text, "")
+			continue
+		}
+		text = append(text, to+strings.TrimPrefix(n.Indent, from)+n.Text)
+	}
+	return text
+}
+
+// file is a flattened view of a syntax tree for inserting & deleting lines
+type file struct {
+	lines []*lib.SyntaxNode
+	index map[*lib.SyntaxNode]int
+	// eol is the line ending of new lines
+	eol string
+	// unit is the indentation of one level of nesting, as written in the tree
+	unit string
+}
+
+func newFile(tree *lib.SyntaxTree) *file {
+	f := &file{index: map[*lib.SyntaxNode]int{}, eol: "\n", unit: "  "}
+	eol, unit := false, false
+	tree.Inspect(func(n *lib.SyntaxNode, depth int) bool {
+		f.index[n] = len(f.lines)
+		f.lines = append(f.lines, n)
+		if !eol && n.EOL != "" {
+			f.eol, eol = n.EOL, true
+		}
+		for _, c := range n.Children {
+			if !unit && n.Kind != lib.ForeignLine && isSignificant(c) && len(c.Indent) > len(n.Indent) && strings.HasPrefix(c.Indent, n.Indent) {
+				f.unit, unit = c.Indent[len(n.Indent):], true
+			}
+		}
+		return true
+	})
+	return f
+}
+
+func isSignificant(n *lib.SyntaxNode) bool {
+	return n.Kind == lib.KeywordLine || n.Kind == lib.TextLine || n.Kind == lib.CommentLine
+}
+
+// childIndent returns the indentation of lines nested within n
+func (f *file) childIndent(n *lib.SyntaxNode) string {
+	for _, c := range n.Children {
+		if isSignificant(c) {
+			return c.Indent
+		}
+	}
+	return n.Indent + f.unit
+}
+
+// span lists the lines from start through the last line nested in end
+func (f *file) span(start, end *lib.SyntaxNode) []*lib.SyntaxNode {
+	return f.lines[f.index[start] : f.index[lastLine(end)]+1]
+}
+
+// insertAfter adds lines after n & the lines nested within it. Each line is
+// written with margin, which is trimmed for empty lines
+func (f *file) insertAfter(n *lib.SyntaxNode, margin string, lines []string) TextEdit {
+	last := lastLine(n)
+	offset := lineEnd(last)
+	text := f.join(margin, lines)
+	if last.EOL == "" {
+		// the last line of input doesn't have a line ending to follow
+		text = f.eol + strings.TrimSuffix(text, f.eol)
+	}
+	return TextEdit{Start: offset, End: offset, NewText: text}
+}
+
+// insertBefore adds lines before n
+func (f *file) insertBefore(n *lib.SyntaxNode, margin string, lines []string) TextEdit {
+	return TextEdit{Start: n.Pos.Offset, End: n.Pos.Offset, NewText: f.join(margin, lines)}
+}
+
+// insertParams adds a params section to a function. New params sections
+// follow the description, before any other section
+func (f *file) insertParams(fn *lib.SyntaxNode, params []string) TextEdit {
+	indent := f.childIndent(fn)
+	lines := []string{indent + "params:"}
+	for _, p := range params {
+		lines = append(lines, indent+f.unit+p)
+	}
+	for i, n := range fn.Children {
+		if n.Kind == lib.KeywordLine {
+			return f.insertBefore(leadingComments(fn.Children, i), fn.Margin, lines)
+		}
+	}
+	return f.insertAfter(fn, fn.Margin, lines)
+}
+
+func (f *file) join(margin string, lines []string) string {
+	var buf strings.Builder
+	for _, l := range lines {
+		if l == "" {
+			buf.WriteString(strings.TrimRight(margin, " \t"))
+		} else {
+			buf.WriteString(margin + l)
+		}
+		buf.WriteString(f.eol)
+	}
+	return buf.String()
+}
+
+// delete removes the lines of section from start through the lines nested in
+// end. The section itself is removed when nothing else is declared in it
+func (f *file) delete(section, start, end *lib.SyntaxNode) TextEdit {
+	first, last := start, lastLine(end)
+	from, to := indexOf(section.Children, start), indexOf(section.Children, end)
+	empty := true
+	for i, c := range section.Children {
+		if (i < from || i > to) && c.Kind != lib.BlankLine && c.Kind != lib.CommentLine {
+			empty = false
+		}
+	}
+	if empty {
+		first, last = section, lastLine(section)
+	}
+
+	e := TextEdit{Start: first.Pos.Offset, End: lineEnd(last)}
+	if i := f.index[first]; last.EOL == "" && i > 0 {
+		// remove the line ending before the deleted lines instead
+		prev := f.lines[i-1]
+		e.Start = lineEnd(prev) - len(prev.EOL)
+	}
+	return e
+}
@@ -0,0 +1,91 @@
+package extract
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// cacheVersion is mixed into cache keys, invalidating entries whenever the
+// way comments are extracted changes
+const cacheVersion = "outline-extract-v1"
+
+// Cache stores the outline comments of go source files on disk, keyed by a
+// hash of file content. Cached files skip go parsing entirely
+type Cache struct {
+	Dir string
+}
+
+// NewCache creates a cache that stores entries in dir, creating dir if it
+// doesn't exist
+func NewCache(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Cache{Dir: dir}, nil
+}
+
+// DefaultCacheDir is the directory used for caching when no other directory
+// is specified, within the user's cache directory
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "outline"), nil
+}
+
+// key derives a cache key from file content
+func (c *Cache) key(src []byte) string {
+	h := sha256.New()
+	h.Write([]byte(cacheVersion))
+	h.Write(src)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".json")
+}
+
+// get reads a cache entry. ok is false if the entry is missing or unreadable
+func (c *Cache) get(key string) (comments []comment, ok bool) {
+	data, err := ioutil.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+	if err := json.Unmarshal(data, &comments); err != nil {
+		return nil, false
+	}
+	return comments, true
+}
+
+// put writes a cache entry. entries are written to a temp file & renamed into
+// place so concurrent readers never see a partial entry
+func (c *Cache) put(key string, comments []comment) error {
+	data, err := json.Marshal(comments)
+	if err != nil {
+		return err
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), key)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,303 @@
+// Package extract reads outline documents from comments in go source code
+package extract
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/b5/outline/lib"
+)
+
+// Package reads the outline documents in the comments of a go package. path
+// may be a directory or an import path. Documents are returned in source
+// order: files sorted by name, then comments by their position in the file
+func Package(path string, opts ...lib.Option) (lib.Docs, error) {
+	e := &Extractor{Workers: 1, Options: opts}
+	return e.Packages(path)
+}
+
+// File reads the outline documents in the comments of a go source file. If
+// src is nil the file is read from disk. Positions are reported relative to
+// the go source file
+func File(filename string, src []byte, opts ...lib.Option) (lib.Docs, error) {
+	if src == nil {
+		var err error
+		if src, err = ioutil.ReadFile(filename); err != nil {
+			return nil, err
+		}
+	}
+
+	comments, err := outlineComments(filename, src)
+	if err != nil {
+		return nil, err
+	}
+	return parseComments(filename, comments, opts)
+}
+
+// Extractor reads outline documents from many go packages concurrently
+type Extractor struct {
+	// Workers is the number of files read at once. values less than one use
+	// the number of CPUs
+	Workers int
+	// Cache stores the outline comments of previously read files. nil
+	// disables caching
+	Cache *Cache
+	// Options are passed when parsing outline documents
+	Options []lib.Option
+}
+
+// Packages reads the outline documents of packages matching patterns. A
+// pattern is a directory, an import path, or either followed by "/..." to
+// match all packages beneath it. Documents are always returned in source
+// order: packages in the order they match, files sorted by name, then
+// comments by their position in the file, regardless of concurrency
+func (e *Extractor) Packages(patterns ...string) (lib.Docs, error) {
+	dirs, err := Expand(patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	var filenames []string
+	for _, dir := range dirs {
+		names, err := goFiles(dir)
+		if err != nil {
+			return nil, err
+		}
+		filenames = append(filenames, names...)
+	}
+
+	workers := e.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+
+	var (
+		results = make([]lib.Docs, len(filenames))
+		errs    = make([]error, len(filenames))
+		jobs    = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results[j], errs[j] = e.file(filenames[j])
+			}
+		}()
+	}
+	for i := range filenames {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var docs lib.Docs
+	for i, found := range results {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+		docs = append(docs, found...)
+	}
+	return docs, nil
+}
+
+// file reads the outline documents of a single file, using the cache when
+// the file content hasn't changed
+func (e *Extractor) file(filename string) (lib.Docs, error) {
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		key      string
+		comments []comment
+		cached   bool
+	)
+	if e.Cache != nil {
+		key = e.Cache.key(src)
+		comments, cached = e.Cache.get(key)
+	}
+	if !cached {
+		if comments, err = outlineComments(filename, src); err != nil {
+			return nil, err
+		}
+		if e.Cache != nil {
+			// caching is best-effort, failing to write only costs a re-parse
+			e.Cache.put(key, comments)
+		}
+	}
+
+	return parseComments(filename, comments, e.Options)
+}
+
+// comment is the text of a go comment group that contains outline documents
+type comment struct {
+	// Line is the line number of the start of the comment in the go file
+	Line int
+	Text string
+}
+
+// outlineComments parses go source, returning comment groups that contain
+// outline documents
+func outlineComments(filename string, src []byte) ([]comment, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []comment
+	for _, c := range f.Comments {
+		text := commentText(c)
+		if strings.Contains(text, "outline:") {
+			comments = append(comments, comment{Line: fset.Position(c.Pos()).Line, Text: text})
+		}
+	}
+	return comments, nil
+}
+
+// parseComments reads outline documents from comments in a go source file
+func parseComments(filename string, comments []comment, opts []lib.Option) (lib.Docs, error) {
+	var docs lib.Docs
+	for _, c := range comments {
+		options := append([]lib.Option{lib.Filename(filename), lib.LineOffset(c.Line - 1)}, opts...)
+		found, err := lib.Load(strings.NewReader(c.Text), filepath.Dir(filename), options...)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, found...)
+	}
+	return docs, nil
+}
+
+// commentText strips comment markers from a comment group, keeping one line
+// of text per line of source so positions within the text map to source lines
+func commentText(g *ast.CommentGroup) string {
+	var lines []string
+	for _, c := range g.List {
+		text := c.Text
+		if strings.HasPrefix(text, "//") {
+			text = strings.TrimPrefix(text[2:], " ")
+			lines = append(lines, text)
+			continue
+		}
+		text = strings.TrimSuffix(strings.TrimPrefix(text, "/*"), "*/")
+		lines = append(lines, strings.Split(text, "\n")...)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Expand converts package patterns to a list of package directories. Patterns
+// ending in "/..." match every directory beneath the pattern root that
+// contains go files, skipping vendor & testdata directories and directories
+// that begin with "." or "_". Each directory is listed once, in the order it's
+// first matched
+func Expand(patterns ...string) ([]string, error) {
+	var (
+		dirs []string
+		seen = map[string]bool{}
+	)
+	add := func(dir string) {
+		if !seen[dir] {
+			seen[dir] = true
+			dirs = append(dirs, dir)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if pattern != "..." && !strings.HasSuffix(pattern, "/...") {
+			dir, err := packageDir(pattern)
+			if err != nil {
+				return nil, err
+			}
+			add(filepath.Clean(dir))
+			continue
+		}
+
+		root := strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+		if root == "" {
+			root = "."
+		}
+		root, err := packageDir(root)
+		if err != nil {
+			return nil, err
+		}
+
+		// filepath.Walk visits directories in lexical order
+		err = filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !fi.IsDir() {
+				return nil
+			}
+			name := fi.Name()
+			if path != root && (name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			if files, err := goFiles(path); err == nil && len(files) > 0 {
+				add(filepath.Clean(path))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return dirs, nil
+}
+
+// packageDir finds the directory of a package from a path on disk or an
+// import path
+func packageDir(path string) (string, error) {
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		return path, nil
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	pkg, err := build.Import(path, wd, build.FindOnly)
+	if err != nil {
+		return "", err
+	}
+	return pkg.Dir, nil
+}
+
+// goFiles lists the non-test go files in a directory that are part of the
+// build for the current platform, sorted by name. Files excluded by build
+// constraints or _GOOS & _GOARCH filename suffixes are skipped
+func goFiles(dir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var filenames []string
+	for _, m := range matches {
+		if strings.HasSuffix(m, "_test.go") {
+			continue
+		}
+		ok, err := build.Default.MatchFile(dir, filepath.Base(m))
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			filenames = append(filenames, m)
+		}
+	}
+	sort.Strings(filenames)
+	return filenames, nil
+}
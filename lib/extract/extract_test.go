@@ -0,0 +1,170 @@
+package extract
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const srcB = `package time
+
+/*
+outline: time
+  functions:
+    now() time
+*/
+
+// outline: geo
+//   functions:
+//     point(lat, lng float) point
+func point() {}
+`
+
+const srcA = `package time
+
+// outline: time
+//   time tells time
+//   functions:
+//     parse(str string) time
+func parse() {}
+`
+
+func TestPackage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "outline_extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"b.go":      srcB,
+		"a.go":      srcA,
+		"a_test.go": "package time\n// outline: test\n",
+		// files excluded from the build aren't extracted
+		"c_plan9.go": "package time\n\n// outline: plan9\n//   functions:\n//     now() time\nfunc now() {}\n",
+		"gen.go":     "//go:build ignore\n// +build ignore\n\npackage main\n\n// outline: gen\n//   functions:\n//     main()\nfunc main() {}\n",
+	}
+	for name, src := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	docs, err := Package(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, doc := range docs {
+		for _, fn := range doc.Functions {
+			got = append(got, fn.Pos().String()+" "+doc.Name+"."+fn.Name())
+		}
+	}
+	expect := []string{
+		filepath.Join(dir, "a.go") + ":6:5 time.parse",
+		filepath.Join(dir, "b.go") + ":6:5 time.now",
+		filepath.Join(dir, "b.go") + ":11:5 geo.point",
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("source order mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func writeTree(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "outline_extract")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, src := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestExpand(t *testing.T) {
+	dir := writeTree(t, map[string]string{
+		"a.go":               srcA,
+		"b/b.go":             srcB,
+		"b/c/c.go":           srcA,
+		"b/testdata/x.go":    srcA,
+		"vendor/v/v.go":      srcA,
+		"_skip/s.go":         srcA,
+		"empty/readme.md":    "",
+		"d/only_test.go":     srcA,
+		"b/c/.hidden/h.go":   srcA,
+		"b/c/nested/deep.go": srcA,
+	})
+	defer os.RemoveAll(dir)
+
+	dirs, err := Expand(filepath.Join(dir, "b"), dir+"/...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		filepath.Join(dir, "b"),
+		dir,
+		filepath.Join(dir, "b/c"),
+		filepath.Join(dir, "b/c/nested"),
+	}
+	if diff := cmp.Diff(expect, dirs); diff != "" {
+		t.Errorf("expanded directories mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestExtractorConcurrencyAndCache(t *testing.T) {
+	files := map[string]string{}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		files[name+"/a.go"] = srcA
+		files[name+"/b.go"] = srcB
+	}
+	dir := writeTree(t, files)
+	defer os.RemoveAll(dir)
+
+	serial, err := (&Extractor{Workers: 1}).Packages(dir + "/...")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cache, err := NewCache(filepath.Join(dir, ".cache"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := &Extractor{Workers: 8, Cache: cache}
+	for i := 0; i < 3; i++ {
+		docs, err := e.Packages(dir + "/...")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(docs) != len(serial) {
+			t.Fatalf("run %d: expected %d documents, got: %d", i, len(serial), len(docs))
+		}
+		for j := range docs {
+			if docs[j].Pos() != serial[j].Pos() {
+				t.Fatalf("run %d: document %d position mismatch. expected: %s, got: %s", i, j, serial[j].Pos(), docs[j].Pos())
+			}
+		}
+	}
+
+	// cached entries are used in place of parsing go source
+	entry := cache.path(cache.key([]byte(srcA)))
+	if err := ioutil.WriteFile(entry, []byte(`[{"Line":1,"Text":"outline: cached"}]`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	docs, err := e.Packages(filepath.Join(dir, "a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs[0].Name != "cached" {
+		t.Errorf("expected document to be read from cache, got: %q", docs[0].Name)
+	}
+}
@@ -0,0 +1,364 @@
+package lib
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"text/template"
+)
+
+// TemplateFuncs returns the function library available to documentation
+// templates. Functions that take a document element accept *Doc, *Type,
+// *Function, *Param & *Field values as found in templates:
+//
+//	anchor     URL fragment identifier for a document, type, function or name
+//	link       markdown for a param, field or return type, linking declared types
+//	qualify    param, field or return type with declared types fully qualified,
+//	           eg: "[point,line]" becomes "[geo.point,geo.line]"
+//	args       parameter names of a function, read from the signature when the
+//	           function has no params section
+//	mermaid    Mermaid class diagram of the types in a document or list of documents
+//	diagrams   report whether type diagrams should be embedded in output, false
+//	           unless overridden by the caller
+//	filename   file a document is written to when writing one file per document,
+//	           empty when all documents are written together
+//	signature  function signature qualified with its receiver, eg: "time.now() time"
+//	code       wrap text in a markdown code span, escaping backticks
+//	mdEscape   escape text for use in a markdown table cell
+//	rst        convert a markdown description to reStructuredText
+//	roff       escape text for a man page. descriptions are written as roff
+//	           paragraphs, lists & code blocks
+//	inline     flatten a description to a single line
+//	join       join a list of strings or named elements with a separator: join ", " .Params
+//	indent     indent every line of text by a number of spaces: indent 4 .Description
+//	wrap       wrap text at a line width: wrap 80 .Description
+//	trimExt    remove the extension from a filename
+//	lower      lower-case text
+//	upper      upper-case text
+//	trim       remove leading & trailing whitespace
+//	replace    replace all occurrences of a string: replace "old" "new" .Name
+//	repeat     repeat a string a number of times: repeat 3 "="
+//	hasPrefix  report whether text begins with a prefix: hasPrefix "_" .Name
+//	hasSuffix  report whether text ends with a suffix
+//	contains   report whether text contains a substring
+func TemplateFuncs(t *SymbolTable) template.FuncMap {
+	return template.FuncMap{
+		"anchor":    t.Anchor,
+		"link":      t.Link,
+		"qualify":   t.Qualify,
+		"args":      args,
+		"mermaid":   func(v interface{}) (string, error) { return mermaid(t, v) },
+		"diagrams":  func() bool { return false },
+		"filename":  func(*Doc) string { return "" },
+		"signature": signature,
+		"code":      code,
+		"mdEscape":  mdEscape,
+		"rst":       rst,
+		"roff":      roff,
+		"inline":    inline,
+		"join":      join,
+		"indent":    indent,
+		"wrap":      wrap,
+		"trimExt":   func(s string) string { return strings.TrimSuffix(s, filepath.Ext(s)) },
+		"lower":     func(s interface{}) string { return strings.ToLower(toString(s)) },
+		"upper":     func(s interface{}) string { return strings.ToUpper(toString(s)) },
+		"trim":      func(s interface{}) string { return strings.TrimSpace(toString(s)) },
+		"replace":   func(old, new string, s interface{}) string { return strings.Replace(toString(s), old, new, -1) },
+		"repeat":    func(n int, s string) string { return strings.Repeat(s, n) },
+		"hasPrefix": func(prefix string, s interface{}) bool { return strings.HasPrefix(toString(s), prefix) },
+		"hasSuffix": func(suffix string, s interface{}) bool { return strings.HasSuffix(toString(s), suffix) },
+		"contains":  func(substr string, s interface{}) bool { return strings.Contains(toString(s), substr) },
+	}
+}
+
+// toString converts template values to strings. Descriptions & other named
+// string types are converted directly, anything else is formatted with fmt
+func toString(v interface{}) string {
+	switch x := v.(type) {
+	case string:
+		return x
+	case fmt.Stringer:
+		return x.String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// signature qualifies a function signature with the function receiver
+func signature(fn *Function) string {
+	if fn.Receiver == "" {
+		return fn.Signature
+	}
+	return fn.Receiver + "." + fn.Signature
+}
+
+// mermaid writes a Mermaid class diagram for a *Doc or Docs
+func mermaid(t *SymbolTable, v interface{}) (string, error) {
+	var docs Docs
+	switch x := v.(type) {
+	case *Doc:
+		docs = Docs{x}
+	case Docs:
+		docs = x
+	default:
+		return "", fmt.Errorf("mermaid: expected a document or list of documents, got %T", v)
+	}
+
+	buf := &strings.Builder{}
+	err := t.WriteMermaid(buf, docs)
+	return buf.String(), err
+}
+
+// args lists the parameter names of a function. Names are read from the
+// function signature when the function has no params section, eg: "lat" &
+// "lng" for "point(lat,lng float)"
+func args(fn *Function) (names []string) {
+	if len(fn.Params) > 0 {
+		for _, p := range fn.Params {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	start, end := strings.Index(fn.Signature, "("), strings.LastIndex(fn.Signature, ")")
+	if start == -1 || end < start {
+		return nil
+	}
+	for _, arg := range strings.Split(fn.Signature[start+1:end], ",") {
+		if fields := strings.Fields(arg); len(fields) > 0 {
+			names = append(names, fields[0])
+		}
+	}
+	return names
+}
+
+// code wraps text in a markdown code span. The span is delimited by a run of
+// backticks longer than any run within the text
+func code(v interface{}) string {
+	s := toString(v)
+	longest, run := 0, 0
+	for _, ch := range s {
+		if ch == '`' {
+			run++
+			if run > longest {
+				longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+
+	fence := strings.Repeat("`", longest+1)
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// mdEscape makes text safe for a markdown table cell by escaping pipes &
+// replacing line breaks with spaces. Descriptions are flattened first
+func mdEscape(v interface{}) string {
+	s := toString(v)
+	if d, ok := v.(Description); ok {
+		s = d.Inline()
+	}
+	s = strings.Replace(s, "|", `\|`, -1)
+	return strings.Join(strings.Fields(strings.Replace(s, "\n", " ", -1)), " ")
+}
+
+// rst converts markdown to reStructuredText. Inline code spans are written
+// with double backticks. Descriptions are broken into blocks, writing lists
+// as bullet or auto-numbered lists & fenced code as code-block directives
+func rst(v interface{}) string {
+	d, ok := v.(Description)
+	if !ok {
+		return rstInline(toString(v))
+	}
+
+	var blocks []string
+	for _, b := range d.Blocks() {
+		switch b.Type {
+		case ListBlock:
+			marker := "- "
+			if b.Ordered {
+				marker = "#. "
+			}
+			items := make([]string, len(b.Items))
+			for i, item := range b.Items {
+				items[i] = marker + rstInline(item)
+			}
+			blocks = append(blocks, strings.Join(items, "\n"))
+		case CodeBlock:
+			directive := "::"
+			if b.Lang != "" {
+				directive = ".. code-block:: " + b.Lang
+			}
+			blocks = append(blocks, directive+"\n\n"+indent(3, b.Text))
+		default:
+			blocks = append(blocks, rstInline(b.Text))
+		}
+	}
+	return strings.Join(blocks, "\n\n")
+}
+
+// rstInline doubles the single backticks that delimit markdown code spans,
+// which are interpreted text in reStructuredText
+func rstInline(s string) string {
+	buf := &strings.Builder{}
+	run := 0
+	flush := func() {
+		if run == 1 {
+			run = 2
+		}
+		buf.WriteString(strings.Repeat("`", run))
+		run = 0
+	}
+	for _, ch := range s {
+		if ch == '`' {
+			run++
+			continue
+		}
+		flush()
+		buf.WriteRune(ch)
+	}
+	flush()
+	return buf.String()
+}
+
+// roff escapes text for use in a roff document: backslashes & dashes are
+// escaped, and lines that would be read as requests are guarded. Descriptions
+// are broken into blocks, written as paragraphs, indented lists & no-fill
+// code displays
+func roff(v interface{}) string {
+	d, ok := v.(Description)
+	if !ok {
+		return roffEscape(toString(v))
+	}
+
+	buf := &strings.Builder{}
+	for i, b := range d.Blocks() {
+		if i > 0 {
+			buf.WriteString(".PP\n")
+		}
+		switch b.Type {
+		case ListBlock:
+			for j, item := range b.Items {
+				if b.Ordered {
+					fmt.Fprintf(buf, ".IP %d. 4\n", j+1)
+				} else {
+					buf.WriteString(".IP \\(bu 2\n")
+				}
+				buf.WriteString(roffEscape(item) + "\n")
+			}
+		case CodeBlock:
+			buf.WriteString(".RS 4\n.nf\n" + roffEscape(b.Text) + "\n.fi\n.RE\n")
+		default:
+			buf.WriteString(roffEscape(b.Text) + "\n")
+		}
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// roffEscape escapes plain text for roff
+func roffEscape(s string) string {
+	s = strings.Replace(s, `\`, `\e`, -1)
+	s = strings.Replace(s, "-", `\-`, -1)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = `\&` + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// inline flattens a description to a single line
+func inline(v interface{}) string {
+	return Description(toString(v)).Inline()
+}
+
+// join concatenates a list with a separator. Elements may be strings,
+// stringers, or values with a Name field or method
+func join(sep string, list interface{}) string {
+	if strs, ok := list.([]string); ok {
+		return strings.Join(strs, sep)
+	}
+
+	rv := reflect.ValueOf(list)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return toString(list)
+	}
+
+	strs := make([]string, rv.Len())
+	for i := range strs {
+		strs[i] = elementName(rv.Index(i).Interface())
+	}
+	return strings.Join(strs, sep)
+}
+
+// elementName returns the name of an outline element
+func elementName(v interface{}) string {
+	switch x := v.(type) {
+	case *Function:
+		return x.Name()
+	case *Operator:
+		return x.Opr
+	case string, fmt.Stringer:
+		return toString(x)
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() == reflect.Struct {
+		if f := rv.FieldByName("Name"); f.IsValid() && f.Kind() == reflect.String {
+			return f.String()
+		}
+	}
+	return fmt.Sprint(v)
+}
+
+// indent prefixes each non-empty line of text with n spaces
+func indent(n int, v interface{}) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.Split(toString(v), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// wrap breaks paragraphs of text into lines no longer than width where
+// possible. Existing line breaks are kept, words longer than width aren't
+// broken
+func wrap(width int, v interface{}) string {
+	lines := strings.Split(toString(v), "\n")
+	for i, line := range lines {
+		words := strings.Fields(line)
+		if len(words) == 0 {
+			lines[i] = ""
+			continue
+		}
+
+		buf := &strings.Builder{}
+		n := 0
+		for j, w := range words {
+			if j > 0 {
+				if n+1+len(w) > width {
+					buf.WriteString("\n")
+					n = 0
+				} else {
+					buf.WriteString(" ")
+					n++
+				}
+			}
+			buf.WriteString(w)
+			n += len(w)
+		}
+		lines[i] = buf.String()
+	}
+	return strings.Join(lines, "\n")
+}
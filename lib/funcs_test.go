@@ -0,0 +1,82 @@
+package lib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+func TestTemplateFuncs(t *testing.T) {
+	docs, err := Parse(strings.NewReader(resolveText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _ := docs.Resolve()
+	funcs := TemplateFuncs(table)
+
+	cases := []struct {
+		tmpl, expect string
+	}{
+		{`{{ anchor . }}`, "module-geo"},
+		{`{{ range .Functions }}{{ anchor . }} {{ end }}`, "function-geo-point function-geo-within "},
+		{`{{ with index .Functions 1 }}{{ link (index .Params 0) }}{{ end }}`, "[[point](#type-geo-point),[line](#type-geo-line),[polygon](#type-geo-polygon)]"},
+		{`{{ with index .Functions 1 }}{{ qualify (index .Params 0) }}{{ end }}`, "[geo.point,geo.line,geo.polygon]"},
+		{`{{ join ", " (args (index .Functions 1)) }}`, "geomA, geomB"},
+		{`{{ signature (index .Functions 0) }}`, "geo.point(lat,lng float) point"},
+		{`{{ join ", " (index .Functions 0).Params }}`, "lat, lng"},
+		{`{{ join "|" .Types }}`, "point|line|polygon"},
+		{`{{ join " " (index .Types 0).Methods }}`, "buffer travel"},
+		{"{{ code \"a`b\" }}", "``a`b``"},
+		{"{{ code \"`a\" }}", "`` `a ``"},
+		{`{{ mdEscape "a | b\nc" }}`, `a \| b c`},
+		{"{{ rst \"a `b` ``c``\" }}", "a ``b`` ``c``"},
+		{`{{ roff "a\\b -c\n.d" }}`, "a\\eb \\-c\n\\&.d"},
+		{`{{ indent 2 "a\n\nb" }}`, "  a\n\n  b"},
+		{`{{ wrap 10 "the quick brown fox jumps" }}`, "the quick\nbrown fox\njumps"},
+		{`{{ lower "ABC" }} {{ upper .Name }} {{ trim "  x " }}`, "abc GEO x"},
+		{`{{ replace "o" "0" .Name }} {{ repeat 3 "=" }}`, "ge0 ==="},
+		{`{{ hasPrefix "ge" .Name }} {{ hasSuffix "x" .Name }} {{ contains "e" .Name }}`, "true false true"},
+	}
+
+	for _, c := range cases {
+		tmpl, err := template.New("test").Funcs(funcs).Parse(c.tmpl)
+		if err != nil {
+			t.Fatalf("parsing %q: %s", c.tmpl, err)
+		}
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, docs[0]); err != nil {
+			t.Fatalf("executing %q: %s", c.tmpl, err)
+		}
+		if buf.String() != c.expect {
+			t.Errorf("%s: expected: %q, got: %q", c.tmpl, c.expect, buf.String())
+		}
+	}
+}
+
+func TestRoffDescription(t *testing.T) {
+	desc := Description("intro text\n\n- one\n- two\n\n```\n.x = -1\n```")
+	expect := `intro text
+.PP
+.IP \(bu 2
+one
+.IP \(bu 2
+two
+.PP
+.RS 4
+.nf
+\&.x = \-1
+.fi
+.RE`
+	if got := roff(desc); got != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, got)
+	}
+}
+
+func TestRstDescription(t *testing.T) {
+	desc := Description("intro `text`\n\n1. one\n2. two\n\n```python\nx = 1\n```")
+	expect := "intro ``text``\n\n#. one\n#. two\n\n.. code-block:: python\n\n   x = 1"
+	if got := rst(desc); got != expect {
+		t.Errorf("expected:\n%s\ngot:\n%s", expect, got)
+	}
+}
@@ -0,0 +1,46 @@
+package lib
+
+import "strings"
+
+// indentation measures the indentation level of lines within a document,
+// relative to the leading whitespace of the document's "outline:" line. Each
+// tab is a level, as is each unit of spaces. When no unit is configured the
+// first indentation written in spaces sets it. The parser & syntax trees
+// share these rules, so both nest lines the same way
+type indentation struct {
+	tabs, spaces int // leading whitespace of the "outline:" line
+	unit         int // number of spaces per indentation level
+}
+
+// begin sets ws as the base indentation of a new document
+func (in *indentation) begin(ws string, unit int) {
+	in.tabs = strings.Count(ws, "\t")
+	in.spaces = len(ws) - in.tabs
+	in.unit = unit
+}
+
+// relative counts the tabs & spaces of ws beyond those of the document line.
+// Counts are negative for lines indented less than the document
+func (in *indentation) relative(ws string) (tabs, spaces int) {
+	tabs = strings.Count(ws, "\t")
+	return tabs - in.tabs, len(ws) - tabs - in.spaces
+}
+
+// level converts relative tabs & spaces to a level, rounding partial levels
+// to the nearest level. Anything less indented than the document line is
+// always at least one level below it
+func (in *indentation) level(tabs, spaces int) int {
+	if spaces == 0 {
+		return tabs
+	}
+	if in.unit == 0 {
+		in.unit = spaces
+		if in.unit < 0 {
+			in.unit = -in.unit
+		}
+	}
+	if spaces < 0 {
+		return tabs + (spaces-in.unit+1)/in.unit
+	}
+	return tabs + (spaces+in.unit/2)/in.unit
+}
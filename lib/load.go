@@ -0,0 +1,189 @@
+package lib
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LoadFile parses the outline documents in a file, merging in the documents
+// of any files named by include directives. Include paths are relative to
+// the file that contains them
+func LoadFile(path string, opts ...Option) (Docs, error) {
+	l := &loader{opts: opts}
+	return l.loadFile(path)
+}
+
+// Load parses outline documents from a reader, resolving include directives
+// relative to dir
+func Load(r io.Reader, dir string, opts ...Option) (Docs, error) {
+	l := &loader{opts: opts}
+	docs, err := Parse(r, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return docs, l.includeAll(docs, dir)
+}
+
+// loader tracks the chain of included files to detect cycles, & the files
+// already included into each document
+type loader struct {
+	opts     []Option
+	stack    []string
+	included map[*Doc]map[string]bool
+}
+
+func (l *loader) loadFile(path string) (Docs, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, p := range l.stack {
+		if p == abs {
+			cycle := strings.Join(append(append([]string{}, l.stack[i:]...), abs), " -> ")
+			return nil, fmt.Errorf("include cycle: %s", cycle)
+		}
+	}
+	l.stack = append(l.stack, abs)
+	defer func() { l.stack = l.stack[:len(l.stack)-1] }()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	opts := append([]Option{Filename(path)}, l.opts...)
+	docs, err := Parse(f, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return docs, l.includeAll(docs, filepath.Dir(path))
+}
+
+// includeAll merges included files into each document & submodule. A
+// document's own functions & types come first, followed by those of each
+// included file in the order the include directives are written. Included
+// files have their own includes merged before they're merged into the
+// including document
+func (l *loader) includeAll(docs Docs, dir string) error {
+	for _, doc := range docs.Modules() {
+		for _, inc := range doc.Includes {
+			path := inc
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			abs, err := filepath.Abs(path)
+			if err != nil {
+				return fmt.Errorf("%s: include %s: %s", doc.Pos(), inc, err)
+			}
+			if l.included[doc][abs] {
+				continue
+			}
+
+			included, err := l.loadFile(path)
+			if err != nil {
+				return fmt.Errorf("%s: include %s: %s", doc.Pos(), inc, err)
+			}
+			if l.included == nil {
+				l.included = map[*Doc]map[string]bool{}
+			}
+			if l.included[doc] == nil {
+				l.included[doc] = map[string]bool{}
+			}
+			l.included[doc][abs] = true
+
+			srcs := includedDocs(doc, included)
+			if len(srcs) == 0 {
+				doc.errorf("include %s: no document named %q", inc, doc.Basename())
+			}
+			for _, src := range srcs {
+				include(doc, src, inc)
+			}
+		}
+	}
+	return nil
+}
+
+// includedDocs picks the documents of an included file that describe doc: a
+// file with a single document describes the including document whatever its
+// name, otherwise only documents with the same name are included
+func includedDocs(doc *Doc, docs Docs) Docs {
+	if len(docs) == 1 {
+		return docs
+	}
+	var matched Docs
+	for _, src := range docs {
+		if src.Basename() == doc.Basename() {
+			matched = append(matched, src)
+		}
+	}
+	return matched
+}
+
+// include adds the functions, types & imports of src to doc. Functions &
+// types that doc already declares are reported at their included position
+// instead of being added, unless they're the same declaration included twice
+// through different files
+func include(doc, src *Doc, name string) {
+	for _, fn := range src.Functions {
+		if existing := doc.function(fn.Name()); existing != nil {
+			if !samePos(existing.pos, fn.pos) {
+				doc.errorAt(fn.pos, "include %s: function %q is already declared in %q at %s", name, fn.Name(), doc.Name, existing.pos)
+			}
+			continue
+		}
+		cp := *fn
+		cp.Receiver = doc.Name
+		doc.Functions = append(doc.Functions, &cp)
+	}
+
+	for _, t := range src.Types {
+		if existing := doc.typ(t.Name); existing != nil {
+			if !samePos(existing.pos, t.pos) {
+				doc.errorAt(t.pos, "include %s: type %q is already declared in %q at %s", name, t.Name, doc.Name, existing.pos)
+			}
+			continue
+		}
+		doc.Types = append(doc.Types, t)
+	}
+
+	for _, imp := range src.Imports {
+		if !contains(doc.Imports, imp) && imp != doc.Name {
+			doc.Imports = append(doc.Imports, imp)
+			doc.addImport(imp, src.importAt(imp))
+		}
+	}
+
+	for _, diag := range src.diagnostics {
+		if !containsDiagnostic(doc.diagnostics, diag) {
+			doc.diagnostics = append(doc.diagnostics, diag)
+		}
+	}
+}
+
+// samePos reports whether two known positions refer to the same place
+func samePos(a, b Position) bool {
+	return a.Line != 0 && a == b
+}
+
+func containsDiagnostic(diags []Diagnostic, diag Diagnostic) bool {
+	for _, d := range diags {
+		if d == diag {
+			return true
+		}
+	}
+	return false
+}
+
+func contains(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
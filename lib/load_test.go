@@ -0,0 +1,209 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func writeOutlines(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "outline_load")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := writeOutlines(t, map[string]string{
+		"geo.outline": `outline: geo
+  include: shapes/shapes.outline
+  import: time
+  functions:
+    point(lat,lng) point
+  types:
+    point
+      fields:
+        elapsed duration`,
+		"shapes/shapes.outline": `outline: shapes
+  include:
+    polygon.outline
+  functions:
+    line(a,b point) line
+  types:
+    line
+    point`,
+		"shapes/polygon.outline": `outline: shapes
+  types:
+    polygon`,
+		"time.outline": `outline: time
+  types:
+    duration`,
+	})
+	defer os.RemoveAll(dir)
+
+	docs, err := LoadFile(filepath.Join(dir, "geo.outline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("expected 1 document, got: %d", len(docs))
+	}
+	geo := docs[0]
+
+	var names []string
+	for _, fn := range geo.Functions {
+		names = append(names, fn.Receiver+"."+fn.Name())
+	}
+	for _, typ := range geo.Types {
+		names = append(names, typ.Name)
+	}
+	expect := []string{"geo.point", "geo.line", "point", "line", "polygon"}
+	if diff := cmp.Diff(expect, names); diff != "" {
+		t.Errorf("merge order mismatch (-want +got):\n%s", diff)
+	}
+
+	diags := geo.Diagnostics()
+	if len(diags) != 1 || !strings.Contains(diags[0].Message, `type "point" is already declared in "geo"`) {
+		t.Errorf("expected a conflict diagnostic for type point, got: %v", diags)
+	}
+
+	time, err := LoadFile(filepath.Join(dir, "time.outline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, diags := append(docs, time...).Resolve()
+	if len(diags) != 0 {
+		t.Errorf("expected imported types to resolve, got: %v", diags)
+	}
+	if s := table.LookupType("duration", geo); s == nil || s.Name != "time.duration" {
+		t.Errorf("expected duration to resolve through import, got: %#v", s)
+	}
+}
+
+func TestLoadFileCycle(t *testing.T) {
+	dir := writeOutlines(t, map[string]string{
+		"a.outline": "outline: a\n  include: b.outline\n",
+		"b.outline": "outline: b\n  include: a.outline\n",
+	})
+	defer os.RemoveAll(dir)
+
+	_, err := LoadFile(filepath.Join(dir, "a.outline"))
+	if err == nil {
+		t.Fatal("expected an include cycle error")
+	}
+	cycle := "include cycle: " + strings.Join([]string{
+		filepath.Join(dir, "a.outline"),
+		filepath.Join(dir, "b.outline"),
+		filepath.Join(dir, "a.outline"),
+	}, " -> ")
+	if !strings.HasSuffix(err.Error(), cycle) {
+		t.Errorf("error mismatch. expected suffix: %q, got: %q", cycle, err)
+	}
+}
+
+func TestLoadFileDiamond(t *testing.T) {
+	dir := writeOutlines(t, map[string]string{
+		"a.outline": "outline: a\n  include:\n    b.outline\n    c.outline\n",
+		"b.outline": "outline: b\n  include: d.outline\n",
+		"c.outline": "outline: c\n  include: d.outline\n",
+		"d.outline": "outline: d\n  functions:\n    now() time\n  types:\n    time\n",
+	})
+	defer os.RemoveAll(dir)
+
+	docs, err := LoadFile(filepath.Join(dir, "a.outline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := docs[0]
+	if len(a.Functions) != 1 || len(a.Types) != 1 {
+		t.Errorf("expected d's function & type to be included once, got: %d functions, %d types", len(a.Functions), len(a.Types))
+	}
+	if diags := a.Diagnostics(); len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got: %v", diags)
+	}
+}
+
+func TestLoadFileSubmodule(t *testing.T) {
+	dir := writeOutlines(t, map[string]string{
+		"http.outline":   "outline: http\n  outline: client\n    include: client.outline\n",
+		"client.outline": "outline: client\n  functions:\n    get(url string)\n",
+	})
+	defer os.RemoveAll(dir)
+
+	docs, err := LoadFile(filepath.Join(dir, "http.outline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := docs[0].Submodules[0]
+	if len(client.Functions) != 1 || client.Functions[0].Receiver != client.Name {
+		t.Errorf("expected get to be included into %s, got: %#v", client.Name, client.Functions)
+	}
+}
+
+func TestLoadFileMultipleDocs(t *testing.T) {
+	dir := writeOutlines(t, map[string]string{
+		"geo.outline": "outline: geo\n  include: both.outline\n",
+		"both.outline": `outline: geo
+  types:
+    point
+
+outline: time
+  types:
+    duration`,
+	})
+	defer os.RemoveAll(dir)
+
+	docs, err := LoadFile(filepath.Join(dir, "geo.outline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	geo := docs[0]
+	if len(geo.Types) != 1 || geo.Types[0].Name != "point" {
+		t.Errorf("expected only the geo document to be included, got: %#v", geo.Types)
+	}
+}
+
+func TestLoadFileShared(t *testing.T) {
+	dir := writeOutlines(t, map[string]string{
+		"shared.outline":   "outline: shared\n  functions:\n    now()\n  types:\n    now\n",
+		"conflict.outline": "outline: conflict\n  include: shared.outline\n  types:\n    now\n",
+	})
+	defer os.RemoveAll(dir)
+
+	shared, err := LoadFile(filepath.Join(dir, "shared.outline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	geo, time := &Doc{Name: "geo"}, &Doc{Name: "time"}
+	include(geo, shared[0], "shared.outline")
+	include(time, shared[0], "shared.outline")
+	for _, doc := range []*Doc{geo, time, shared[0]} {
+		if fn := doc.Functions[0]; fn.Receiver != doc.Name {
+			t.Errorf("expected now() in %s to have receiver %q, got: %q", doc.Name, doc.Name, fn.Receiver)
+		}
+	}
+
+	docs, err := LoadFile(filepath.Join(dir, "conflict.outline"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	diags := docs[0].Diagnostics()
+	expect := Position{Filename: filepath.Join(dir, "shared.outline"), Line: 5, Col: 5}
+	if len(diags) != 1 || diags[0].Pos.Filename != expect.Filename || diags[0].Pos.Line != expect.Line {
+		t.Errorf("expected a conflict at %s, got: %v", expect, diags)
+	}
+}
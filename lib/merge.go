@@ -0,0 +1,228 @@
+package lib
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Merge combines documents that share a name, returning one document per
+// name in the order names are first encountered. Merging is intended for
+// modules that are described across many comments or files:
+//
+//   - functions are combined. Functions declared more than once are reported
+//     with the position of each declaration, keeping the first
+//   - types that share a name are unified, combining fields, methods and
+//     operators. Conflicting fields & duplicate methods are reported
+//   - descriptions & paths are chosen according to MergePrecedence
+//   - submodules are merged the same way, by name
+//   - includes, imports & diagnostics are combined, as are the extensions of
+//     custom sections, with conflicting values chosen by MergePrecedence
+//
+// Merge doesn't modify the input documents
+func (d Docs) Merge(opts ...Option) (Docs, error) {
+	cfg, err := parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		merged Docs
+		byName = map[string]*Doc{}
+	)
+	for _, doc := range d {
+		dst, ok := byName[doc.Name]
+		if !ok {
+			dst = &Doc{
+				cfg:         doc.cfg,
+				pos:         doc.pos,
+				diagnostics: append([]Diagnostic(nil), doc.diagnostics...),
+				Name:        doc.Name,
+				Path:        doc.Path,
+				Includes:    append([]string(nil), doc.Includes...),
+				Imports:     append([]string(nil), doc.Imports...),
+				Description: doc.Description,
+				Extensions:  doc.Extensions,
+				Submodules:  append(Docs(nil), doc.Submodules...),
+			}
+			byName[doc.Name] = dst
+			merged = append(merged, dst)
+		} else {
+			if dst.Path, err = cfg.precedence.choose(dst.Path, doc.Path); err != nil {
+				return nil, fmt.Errorf("%s: merging %q path: %s", doc.pos, doc.Name, err)
+			}
+			var desc string
+			if desc, err = cfg.precedence.choose(string(dst.Description), string(doc.Description)); err != nil {
+				return nil, fmt.Errorf("%s: merging %q description: %s", doc.pos, doc.Name, err)
+			}
+			dst.Description = Description(desc)
+			if dst.Extensions, err = mergeExtensions(dst.Extensions, doc.Extensions, cfg.precedence); err != nil {
+				return nil, fmt.Errorf("%s: merging %q extensions: %s", doc.pos, doc.Name, err)
+			}
+			dst.Submodules = append(dst.Submodules, doc.Submodules...)
+			dst.Includes = union(dst.Includes, doc.Includes)
+			dst.Imports = union(dst.Imports, doc.Imports)
+			dst.diagnostics = append(dst.diagnostics, doc.diagnostics...)
+		}
+		for imp, pos := range doc.importPos {
+			dst.addImport(imp, pos)
+		}
+
+		if doc.Functions != nil && dst.Functions == nil {
+			dst.Functions = Functions{}
+		}
+		dst.Functions = dst.mergeFunctions(dst.Functions, doc.Functions, "function", doc.Name+".")
+
+		if doc.Types != nil && dst.Types == nil {
+			dst.Types = Types{}
+		}
+		for _, t := range doc.Types {
+			existing := dst.typ(t.Name)
+			if existing == nil {
+				dst.Types = append(dst.Types, t.copy())
+				continue
+			}
+			if err := dst.mergeType(existing, t, cfg.precedence); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, dst := range merged {
+		if len(dst.Submodules) > 0 {
+			if dst.Submodules, err = dst.Submodules.Merge(opts...); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return merged, nil
+}
+
+// mergeFunctions appends src functions to dst, reporting functions that are
+// already present
+func (d *Doc) mergeFunctions(dst, src Functions, kind, prefix string) Functions {
+	for _, fn := range src {
+		var existing *Function
+		for _, f := range dst {
+			if f.Name() == fn.Name() {
+				existing = f
+				break
+			}
+		}
+		if existing != nil {
+			d.diagnostics = append(d.diagnostics, Diagnostic{
+				Pos:      fn.pos,
+				Severity: Error,
+				Message:  fmt.Sprintf("%s %q is already declared at %s", kind, prefix+fn.Name(), existing.pos),
+			})
+			continue
+		}
+		dst = append(dst, fn)
+	}
+	return dst
+}
+
+// mergeType unifies src into dst
+func (d *Doc) mergeType(dst, src *Type, prec Precedence) error {
+	desc, err := prec.choose(string(dst.Description), string(src.Description))
+	if err != nil {
+		return fmt.Errorf("%s: merging type %q description: %s", src.pos, src.Name, err)
+	}
+	dst.Description = Description(desc)
+	if dst.Extensions, err = mergeExtensions(dst.Extensions, src.Extensions, prec); err != nil {
+		return fmt.Errorf("%s: merging type %q extensions: %s", src.pos, src.Name, err)
+	}
+
+	dst.Methods = d.mergeFunctions(dst.Methods, src.Methods, "method", dst.Name+".")
+
+	for _, f := range src.Fields {
+		var existing *Field
+		for _, ef := range dst.Fields {
+			if ef.Name == f.Name {
+				existing = ef
+				break
+			}
+		}
+		switch {
+		case existing == nil:
+			dst.Fields = append(dst.Fields, f)
+		case existing.Type != f.Type && existing.Type != "" && f.Type != "":
+			d.diagnostics = append(d.diagnostics, Diagnostic{
+				Pos:      f.pos,
+				Severity: Error,
+				Message:  fmt.Sprintf("field %s.%s is declared as %q at %s and %q at %s", dst.Name, f.Name, existing.Type, existing.pos, f.Type, f.pos),
+			})
+		}
+	}
+
+	for _, o := range src.Operators {
+		dup := false
+		for _, eo := range dst.Operators {
+			if eo.Opr == o.Opr {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			dst.Operators = append(dst.Operators, o)
+		}
+	}
+	return nil
+}
+
+// copy makes a shallow copy of a type, with copied slices of fields, methods
+// & operators that are safe to append to
+func (t *Type) copy() *Type {
+	cp := *t
+	cp.Methods = append(Functions(nil), t.Methods...)
+	cp.Fields = append([]*Field(nil), t.Fields...)
+	cp.Operators = append([]*Operator(nil), t.Operators...)
+	return &cp
+}
+
+// choose picks between an existing and an incoming value
+func (p Precedence) choose(existing, incoming string) (string, error) {
+	switch {
+	case incoming == "" || existing == incoming:
+		return existing, nil
+	case existing == "":
+		return incoming, nil
+	case p == PreferLast:
+		return incoming, nil
+	case p == RequireEqual:
+		return existing, fmt.Errorf("%q conflicts with %q", incoming, existing)
+	default:
+		return existing, nil
+	}
+}
+
+// mergeExtensions adds the extension values of src to dst, choosing between
+// conflicting values by precedence
+func mergeExtensions(dst, src map[string]interface{}, prec Precedence) (map[string]interface{}, error) {
+	if len(src) == 0 {
+		return dst, nil
+	}
+	merged := make(map[string]interface{}, len(dst)+len(src))
+	for name, v := range dst {
+		merged[name] = v
+	}
+	for name, v := range src {
+		existing, ok := merged[name]
+		switch {
+		case !ok || prec == PreferLast:
+			merged[name] = v
+		case prec == RequireEqual && !reflect.DeepEqual(existing, v):
+			return nil, fmt.Errorf("%s: %v conflicts with %v", name, v, existing)
+		}
+	}
+	return merged, nil
+}
+
+// union appends strings in b that aren't present in a
+func union(a, b []string) []string {
+	for _, s := range b {
+		if !contains(a, s) {
+			a = append(a, s)
+		}
+	}
+	return a
+}
@@ -0,0 +1,123 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const mergeA = `outline: time
+  time is a module
+  functions:
+    now() time
+  types:
+    duration
+      a period of time
+      methods:
+        hours() float
+      fields:
+        hours float
+      operators:
+        duration + duration = duration`
+
+const mergeB = `outline: other
+  functions:
+    noop()
+
+outline: time
+  time tells time
+  functions:
+    now() time
+    parse(str string) time
+  types:
+    duration
+      methods:
+        hours() float
+        minutes() float
+      fields:
+        hours int
+        minutes float
+      operators:
+        duration + duration = duration
+        duration - duration = duration
+    time`
+
+func parseMergeDocs(t *testing.T) Docs {
+	a, err := Parse(strings.NewReader(mergeA), Filename("a.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Parse(strings.NewReader(mergeB), Filename("b.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(a, b...)
+}
+
+func TestMerge(t *testing.T) {
+	docs := parseMergeDocs(t)
+	merged, err := docs.Merge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(merged) != 2 || merged[0].Name != "time" || merged[1].Name != "other" {
+		t.Fatalf("expected documents time, other in order of appearance. got: %v", merged)
+	}
+
+	time := merged[0]
+	if time.Description != "time is a module" {
+		t.Errorf("expected first description to take precedence, got: %q", time.Description)
+	}
+
+	var fns []string
+	for _, fn := range time.Functions {
+		fns = append(fns, fn.Name())
+	}
+	if diff := cmp.Diff([]string{"now", "parse"}, fns); diff != "" {
+		t.Errorf("functions mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(time.Types) != 2 {
+		t.Fatalf("expected duration & time types, got: %d types", len(time.Types))
+	}
+	duration := time.Types[0]
+	if len(duration.Methods) != 2 || len(duration.Fields) != 2 || len(duration.Operators) != 2 {
+		t.Errorf("expected duration methods, fields & operators to be combined. got %d methods, %d fields, %d operators",
+			len(duration.Methods), len(duration.Fields), len(duration.Operators))
+	}
+
+	var msgs []string
+	for _, d := range time.Diagnostics() {
+		msgs = append(msgs, d.String())
+	}
+	expect := []string{
+		`b.go:8:5: error: function "time.now" is already declared at a.go:4:5`,
+		`b.go:13:9: error: method "duration.hours" is already declared at a.go:9:9`,
+		`b.go:16:9: error: field duration.hours is declared as "float" at a.go:11:9 and "int" at b.go:16:9`,
+	}
+	if diff := cmp.Diff(expect, msgs); diff != "" {
+		t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+	}
+
+	if len(docs[0].Types[0].Methods) != 1 {
+		t.Error("merge modified input documents")
+	}
+}
+
+func TestMergePrecedence(t *testing.T) {
+	merged, err := parseMergeDocs(t).Merge(MergePrecedence(PreferLast))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged[0].Description != "time tells time" {
+		t.Errorf("expected last description to take precedence, got: %q", merged[0].Description)
+	}
+
+	_, err = parseMergeDocs(t).Merge(MergePrecedence(RequireEqual))
+	expect := `b.go:5:1: merging "time" description: "time tells time" conflicts with "time is a module"`
+	if err == nil || err.Error() != expect {
+		t.Errorf("error mismatch. expected: %q, got: %v", expect, err)
+	}
+}
@@ -0,0 +1,129 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// binaryOperators maps binary operator symbols to starlark syntax token names
+var binaryOperators = map[string]string{
+	"+":      "PLUS",
+	"-":      "MINUS",
+	"*":      "STAR",
+	"/":      "SLASH",
+	"//":     "SLASHSLASH",
+	"%":      "PERCENT",
+	"&":      "AMP",
+	"|":      "PIPE",
+	"^":      "CIRCUMFLEX",
+	"<<":     "LTLT",
+	">>":     "GTGT",
+	"==":     "EQL",
+	"!=":     "NEQ",
+	"<":      "LT",
+	"<=":     "LE",
+	">":      "GT",
+	">=":     "GE",
+	"in":     "IN",
+	"not in": "NOT_IN",
+}
+
+// unaryOperators maps unary operator symbols to starlark syntax token names
+var unaryOperators = map[string]string{
+	"+":   "PLUS",
+	"-":   "MINUS",
+	"~":   "TILDE",
+	"not": "NOT",
+}
+
+// Unary is true for operators that take a single operand, eg: "-duration = duration"
+func (o *Operator) Unary() bool {
+	return o.Left == "" && o.Right != ""
+}
+
+// Expr returns the operator expression without the result type, eg:
+// "duration + time". Returns the line as written for unrecognized operators
+func (o *Operator) Expr() string {
+	switch {
+	case o.Symbol == "":
+		return o.Opr
+	case o.Unary():
+		if len(o.Symbol) == 1 {
+			return o.Symbol + o.Right
+		}
+		return o.Symbol + " " + o.Right
+	default:
+		return o.Left + " " + o.Symbol + " " + o.Right
+	}
+}
+
+// SyntaxToken returns the name of the go.starlark.net/syntax token for the
+// operator symbol, eg: "PLUS" for "+". Stub generators use this to emit
+// Binary & Unary method implementations. Returns the empty string for
+// unrecognized symbols
+func (o *Operator) SyntaxToken() string {
+	if o.Unary() {
+		return unaryOperators[o.Symbol]
+	}
+	return binaryOperators[o.Symbol]
+}
+
+// parseOperator splits an operator line like "duration + time = time" into
+// operand, symbol & result types. Operands & results are type expressions,
+// so they may contain spaces & brackets: "list[int, str] + int = list[int]"
+func parseOperator(line string) (*Operator, error) {
+	op := &Operator{Opr: line}
+
+	eq := strings.LastIndex(line, " = ")
+	if eq == -1 {
+		return op, fmt.Errorf("operator %q is missing a result type. expected a line like: \"a + b = c\"", line)
+	}
+	result := strings.TrimSpace(line[eq+len(" = "):])
+	if _, err := ParseType(result); err != nil {
+		return op, fmt.Errorf("operator %q: %s", line, err)
+	}
+	expr := strings.TrimSpace(line[:eq])
+
+	// binary symbols are written between spaces, outside of any brackets
+	depth := 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+		if depth != 0 || i == 0 || expr[i-1] != ' ' {
+			continue
+		}
+		for sym := range binaryOperators {
+			if !strings.HasPrefix(expr[i:], sym+" ") {
+				continue
+			}
+			left, right := strings.TrimSpace(expr[:i]), strings.TrimSpace(expr[i+len(sym):])
+			if isOperand(left) && isOperand(right) {
+				op.Left, op.Symbol, op.Right, op.Result = left, sym, right, result
+				return op, nil
+			}
+		}
+	}
+
+	for sym := range unaryOperators {
+		rest := strings.TrimPrefix(expr, sym)
+		if rest == expr || (sym == "not" && !strings.HasPrefix(rest, " ")) {
+			continue
+		}
+		if rest = strings.TrimSpace(rest); isOperand(rest) {
+			op.Symbol, op.Right, op.Result = sym, rest, result
+			return op, nil
+		}
+	}
+
+	return op, fmt.Errorf("unrecognized operator expression %q", expr)
+}
+
+// isOperand reports whether text is a type expression an operator can take
+func isOperand(text string) bool {
+	t, err := ParseType(text)
+	return err == nil && t != nil
+}
@@ -1,5 +1,7 @@
 package lib
 
+import "fmt"
+
 type Option interface {
 	apply(cfg *config) error
 }
@@ -7,6 +9,19 @@ type Option interface {
 type config struct {
 	alphaSortTypes bool
 	alphaSortFuncs bool
+	// number of spaces that make up one level of indentation. zero infers
+	// the width from the first indented line of each document
+	indentWidth int
+	// name of the file being parsed, used in positions
+	filename string
+	// number of lines that precede the parsed text within the named file
+	lineOffset int
+	// how Docs.Merge chooses between conflicting document-level values
+	precedence Precedence
+	// report input the parser would otherwise skip or misread as errors
+	strict bool
+	// custom sections added to a single parse, by name
+	sections map[string]*Section
 }
 
 func AlphaSortTypes() Option { return alphaSortTypes{} }
@@ -27,6 +42,107 @@ func (o alphaSortFuncs) apply(cfg *config) error {
 	return nil
 }
 
+// IndentWidth sets the number of spaces that make up one level of indentation,
+// overriding the width inferred from each document. Tabs are always one level
+func IndentWidth(n int) Option { return indentWidth(n) }
+
+type indentWidth int
+
+func (o indentWidth) apply(cfg *config) error {
+	if o < 1 {
+		return fmt.Errorf("indent width must be greater than zero, got %d", o)
+	}
+	cfg.indentWidth = int(o)
+	return nil
+}
+
+// Filename sets the name of the file being parsed. Positions reported in
+// diagnostics include the filename, and include directives are resolved
+// relative to it
+func Filename(name string) Option { return filename(name) }
+
+type filename string
+
+func (o filename) apply(cfg *config) error {
+	cfg.filename = string(o)
+	return nil
+}
+
+// LineOffset shifts the line numbers of reported positions by n lines, for
+// parsing outline text that is embedded in a larger file, like a comment in
+// go source code
+func LineOffset(n int) Option { return lineOffset(n) }
+
+type lineOffset int
+
+func (o lineOffset) apply(cfg *config) error {
+	cfg.lineOffset = int(o)
+	return nil
+}
+
+// Strict makes parsing fail on input that's otherwise silently skipped or
+// read as description text: unknown keywords like "parms:", sections in the
+// wrong place like "fields:" under a function, empty sections, and text that
+// isn't part of any element. Parse returns every problem found as an error of
+// type Diagnostics
+func Strict() Option { return strict{} }
+
+type strict struct{}
+
+func (o strict) apply(cfg *config) error {
+	cfg.strict = true
+	return nil
+}
+
+// Precedence determines which value Docs.Merge keeps when documents or types
+// being merged have different descriptions or paths
+type Precedence int
+
+const (
+	// PreferFirst keeps the first non-empty value encountered
+	PreferFirst Precedence = iota
+	// PreferLast keeps the last non-empty value encountered
+	PreferLast
+	// RequireEqual makes merging fail when non-empty values differ
+	RequireEqual
+)
+
+// String implements the stringer interface for Precedence
+func (p Precedence) String() string {
+	switch p {
+	case PreferFirst:
+		return "first"
+	case PreferLast:
+		return "last"
+	case RequireEqual:
+		return "equal"
+	default:
+		return "unknown"
+	}
+}
+
+// ParsePrecedence converts a string to a Precedence, the inverse of
+// Precedence.String
+func ParsePrecedence(s string) (Precedence, error) {
+	for _, p := range []Precedence{PreferFirst, PreferLast, RequireEqual} {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return PreferFirst, fmt.Errorf("unknown merge precedence %q. expected one of first, last, equal", s)
+}
+
+// MergePrecedence sets how Docs.Merge resolves conflicting descriptions &
+// paths. The default is PreferFirst
+func MergePrecedence(p Precedence) Option { return mergePrecedence(p) }
+
+type mergePrecedence Precedence
+
+func (o mergePrecedence) apply(cfg *config) error {
+	cfg.precedence = Precedence(o)
+	return nil
+}
+
 func parseOptions(opts []Option) (config, error) {
 	cfg := config{}
 	for _, opt := range opts {
@@ -20,50 +20,152 @@ func (d Docs) Less(i, j int) bool { return d[i].Path+d[i].Name < d[j].Path+d[j].
 // Swap implements the sort.Sortable interface
 func (d Docs) Swap(i, j int) { d[i], d[j] = d[j], d[i] }
 
-// Sort sorts all sortable fields in all docs, and the docs list itself
+// errorAt records an error diagnostic at pos
+func (d *Doc) errorAt(pos Position, format string, args ...interface{}) {
+	d.diagnostics = append(d.diagnostics, Diagnostic{
+		Pos:      pos,
+		Severity: Error,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Sort sorts all sortable fields in all docs, and the docs list itself,
+// including the submodules of each document
 func (d Docs) Sort() {
 	for _, doc := range d {
-		doc.Sort()
+		doc.sortElements()
+		doc.Submodules.Sort()
 	}
-	sort.Sort(d)
+	sort.Stable(d)
 }
 
 // Doc is is a documentation document
 type Doc struct {
 	cfg         config
+	pos         Position
+	diagnostics []Diagnostic
+	importPos   map[string]Position // where each import is written
 	Name        string
 	Path        string
-	Description string
+	Includes    []string // outline files merged into this document
+	Imports     []string // modules this document references types from
+	Description Description
 	Functions   Functions
 	Types       Types
+	// Submodules are documents nested within this one, written in a
+	// "modules:" section or as indented "outline:" lines. Submodule names are
+	// qualified with the name of their parent, eg: "http.client"
+	Submodules Docs
+	// Extensions holds the values of custom sections, keyed by section name
+	Extensions map[string]interface{}
 }
 
-// Sort sorts all sortable fields in the document
-func (d *Doc) Sort() {
-	if d.cfg.alphaSortFuncs {
-		sort.Sort(d.Functions)
+// Pos returns the position of the "outline:" line that began the document
+func (d *Doc) Pos() Position {
+	return d.pos
+}
+
+// importAt returns the position of an import, falling back to the position
+// of the document for imports that weren't parsed
+func (d *Doc) importAt(imp string) Position {
+	if pos, ok := d.importPos[imp]; ok {
+		return pos
 	}
-	if d.cfg.alphaSortTypes {
-		sort.Sort(d.Types)
+	return d.pos
+}
+
+// addImport records the position of an import, keeping the first position
+// an import is written at
+func (d *Doc) addImport(imp string, pos Position) {
+	if d.importPos == nil {
+		d.importPos = map[string]Position{}
+	}
+	if _, ok := d.importPos[imp]; !ok {
+		d.importPos[imp] = pos
 	}
 }
 
-// Examples returns a slice of all examples defined in the document
-func (d *Doc) Examples() (egs []*Example) {
-	for _, f := range d.Functions {
-		if len(f.Examples) > 0 {
-			egs = append(egs, f.Examples...)
+// Diagnostics returns any problems found while parsing the document
+func (d *Doc) Diagnostics() []Diagnostic {
+	return d.diagnostics
+}
+
+// function finds a module-level function by name
+func (d *Doc) function(name string) *Function {
+	for _, fn := range d.Functions {
+		if fn.Name() == name {
+			return fn
 		}
 	}
+	return nil
+}
 
+// typ finds a type by name
+func (d *Doc) typ(name string) *Type {
 	for _, t := range d.Types {
-		for _, m := range t.Methods {
-			if len(m.Examples) > 0 {
-				egs = append(egs, m.Examples...)
-			}
+		if t.Name == name {
+			return t
 		}
 	}
+	return nil
+}
+
+// errorf records an error diagnostic positioned at the start of the document
+func (d *Doc) errorf(format string, args ...interface{}) {
+	d.diagnostics = append(d.diagnostics, Diagnostic{
+		Pos:      d.pos,
+		Severity: Error,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Sort sorts all sortable fields in the document & its submodules
+func (d *Doc) Sort() {
+	d.sortElements()
+	for _, mod := range d.Submodules {
+		mod.Sort()
+	}
+}
+
+// sortElements sorts the functions & types of a document, leaving its
+// submodules alone
+func (d *Doc) sortElements() {
+	if d.cfg.alphaSortFuncs {
+		sort.Stable(d.Functions)
+	}
+	if d.cfg.alphaSortTypes {
+		sort.Stable(d.Types)
+	}
+}
+
+// Basename returns the last part of a document's name, the name a submodule
+// is written with within its parent, eg: "client" for "http.client"
+func (d *Doc) Basename() string {
+	return d.Name[strings.LastIndex(d.Name, ".")+1:]
+}
+
+// Modules lists documents & their submodules at any depth, each document
+// followed by its submodules
+func (d Docs) Modules() (mods Docs) {
+	for _, doc := range d {
+		mods = append(mods, doc)
+		mods = append(mods, doc.Submodules.Modules()...)
+	}
+	return mods
+}
 
+// Examples returns a slice of all examples defined in the document
+func (d *Doc) Examples() (egs []*Example) {
+	Inspect(d, func(n Node, _ Path) bool {
+		switch x := n.(type) {
+		case *Example:
+			egs = append(egs, x)
+		case *Doc:
+			// submodules list their own examples
+			return x == d
+		}
+		return true
+	})
 	return egs
 }
 
@@ -80,10 +182,14 @@ func (d *Doc) MarshalIndent(depth int, prefix string) ([]byte, error) {
 		buf.WriteString(strings.Repeat(prefix, depth) + PathTok.String() + ": " + d.Path + "\n")
 		depth--
 	}
+	for _, inc := range d.Includes {
+		buf.WriteString(strings.Repeat(prefix, depth+1) + IncludeTok.String() + ": " + inc + "\n")
+	}
+	for _, imp := range d.Imports {
+		buf.WriteString(strings.Repeat(prefix, depth+1) + ImportTok.String() + ": " + imp + "\n")
+	}
 	if d.Description != "" {
-		depth++
-		buf.WriteString(strings.Repeat(prefix, depth) + d.Description + "\n")
-		depth--
+		writeDescription(buf, strings.Repeat(prefix, depth+1), d.Description)
 	}
 	if d.Functions != nil {
 		depth++
@@ -92,9 +198,7 @@ func (d *Doc) MarshalIndent(depth int, prefix string) ([]byte, error) {
 		for _, fn := range d.Functions {
 			buf.WriteString(strings.Repeat(prefix, depth) + fn.Signature + "\n")
 			if fn.Description != "" {
-				for _, p := range strings.Split(fn.Description, "\n") {
-					buf.WriteString(strings.Repeat(prefix, depth+1) + p + "\n")
-				}
+				writeDescription(buf, strings.Repeat(prefix, depth+1), fn.Description)
 			}
 		}
 		depth -= 2
@@ -107,11 +211,7 @@ func (d *Doc) MarshalIndent(depth int, prefix string) ([]byte, error) {
 		for _, t := range d.Types {
 			buf.WriteString(strings.Repeat(prefix, depth) + t.Name + "\n")
 			if t.Description != "" {
-				depth++
-				for _, p := range strings.Split(t.Description, "\n") {
-					buf.WriteString(strings.Repeat(prefix, depth+1) + p + "\n")
-				}
-				depth--
+				writeDescription(buf, strings.Repeat(prefix, depth+2), t.Description)
 			}
 			if len(t.Fields) > 0 {
 				depth++
@@ -133,6 +233,146 @@ func (d *Doc) MarshalIndent(depth int, prefix string) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// MarshalNode writes any element of the outline model as outline text,
+// including every section of the element: params, return values & examples
+// of functions, and methods, fields & operators of types. The element is
+// written at depth zero, each level of nesting is indented with prefix
+func MarshalNode(n Node, prefix string) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := writeNode(buf, n, nil, 0, prefix); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeNode(buf *bytes.Buffer, n, parent Node, depth int, prefix string) error {
+	line := func(depth int, text string) {
+		buf.WriteString(strings.Repeat(prefix, depth) + text + "\n")
+	}
+	desc := func(depth int, d Description) {
+		if d != "" {
+			writeDescription(buf, strings.Repeat(prefix, depth), d)
+		}
+	}
+	typed := func(name, typ string) string {
+		return strings.TrimSpace(name + " " + typ)
+	}
+
+	switch x := n.(type) {
+	case Docs:
+		for i, doc := range x {
+			if i > 0 {
+				buf.WriteString("\n")
+			}
+			if err := writeNode(buf, doc, nil, depth, prefix); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *Doc:
+		if _, ok := parent.(*Doc); ok {
+			// submodules are entries of their parent's modules section
+			line(depth, x.Basename())
+		} else {
+			line(depth, typed(DocumentTok.String()+":", x.Name))
+		}
+		if x.Path != "" {
+			line(depth+1, PathTok.String()+": "+x.Path)
+		}
+		for _, inc := range x.Includes {
+			line(depth+1, IncludeTok.String()+": "+inc)
+		}
+		for _, imp := range x.Imports {
+			line(depth+1, ImportTok.String()+": "+imp)
+		}
+		desc(depth+1, x.Description)
+		writeExtensions(buf, x.Extensions, strings.Repeat(prefix, depth+1), prefix)
+	case *Function:
+		line(depth, x.Signature)
+		desc(depth+1, x.Description)
+		if x.Return != "" {
+			line(depth+1, ReturnTok.String()+": "+x.Return)
+		}
+		writeExtensions(buf, x.Extensions, strings.Repeat(prefix, depth+1), prefix)
+	case *Type:
+		if len(x.TypeParams) > 0 {
+			line(depth, x.Name+"["+strings.Join(x.TypeParams, ",")+"]")
+		} else {
+			line(depth, x.Name)
+		}
+		desc(depth+1, x.Description)
+		writeExtensions(buf, x.Extensions, strings.Repeat(prefix, depth+1), prefix)
+	case *Param:
+		line(depth, x.Decl())
+		desc(depth+1, x.Description)
+	case *Field:
+		line(depth, typed(x.Name, x.Type))
+		desc(depth+1, x.Description)
+	case *Operator:
+		line(depth, x.Opr)
+		desc(depth+1, x.Description)
+	case *Example:
+		line(depth, x.Name)
+		desc(depth+1, x.Description)
+		if x.Code != "" {
+			line(depth+1, CodeTok.String()+":")
+			for _, l := range strings.Split(x.Code, "\n") {
+				line(depth+2, l)
+			}
+		}
+	default:
+		return fmt.Errorf("cannot marshal %T as outline text", n)
+	}
+
+	// children are written in sections, each headed by a keyword line
+	var section TokenType
+	for _, child := range Children(n) {
+		if tok := sectionTok(n, child); tok != section {
+			section = tok
+			line(depth+1, tok.String()+":")
+		}
+		if err := writeNode(buf, child, n, depth+2, prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sectionTok returns the keyword of the section a child node is written in
+func sectionTok(parent, child Node) TokenType {
+	switch child.(type) {
+	case *Function:
+		if _, ok := parent.(*Type); ok {
+			return MethodsTok
+		}
+		return FunctionsTok
+	case *Type:
+		return TypesTok
+	case *Doc:
+		return ModulesTok
+	case *Param:
+		return ParamsTok
+	case *Field:
+		return FieldsTok
+	case *Operator:
+		return OperatorsTok
+	case *Example:
+		return ExamplesTok
+	}
+	return IllegalTok
+}
+
+// writeDescription writes each line of a description with a leading indent.
+// blank lines separating paragraphs are written without indentation
+func writeDescription(buf *bytes.Buffer, indent string, desc Description) {
+	for _, line := range strings.Split(string(desc), "\n") {
+		if line != "" {
+			buf.WriteString(indent + line)
+		}
+		buf.WriteString("\n")
+	}
+}
+
 // Functions is a sortable slice of Function pointers
 type Functions []*Function
 
@@ -147,21 +387,61 @@ func (f Functions) Swap(i, j int) { f[i], f[j] = f[j], f[i] }
 
 // Function documents a starlark function
 type Function struct {
+	pos         Position
 	FuncName    string
 	Receiver    string // should be set by parsing context
 	Signature   string
-	Description string
+	Description Description
 	Params      []*Param
 	Return      string
 	Examples    []*Example
+	Extensions  map[string]interface{}
+}
+
+// Pos returns the position of the function signature
+func (f *Function) Pos() Position {
+	return f.pos
+}
+
+// Name returns the name of the function, falling back to the signature for
+// functions that are written without parentheses
+func (f *Function) Name() string {
+	if f.FuncName != "" {
+		return f.FuncName
+	}
+	return f.Signature
+}
+
+// ReturnType returns the declared return type of the function, using the
+// text that follows the parameter list in the signature when no "return:"
+// section is present, eg: "int" for "sum(a, b int) int"
+func (f *Function) ReturnType() string {
+	if f.Return != "" {
+		return f.Return
+	}
+	pos := strings.LastIndex(f.Signature, ")")
+	if pos == -1 {
+		return ""
+	}
+	ret := strings.TrimSpace(f.Signature[pos+1:])
+	ret = strings.TrimPrefix(ret, "->")
+	ret = strings.TrimPrefix(ret, ":")
+	return strings.TrimSpace(ret)
 }
 
 // Param is an argument to a function
 type Param struct {
-	Name        string
-	Type        string
-	Optional    bool
-	Description string
+	pos      Position
+	Name     string
+	Type     string
+	Optional bool
+	// Default is the param's default value as written, eg: "\"RFC3339\""
+	Default string
+	// Variadic params accept any number of positional arguments: "*args"
+	Variadic bool
+	// Kwargs params accept any number of keyword arguments: "**kwargs"
+	Kwargs      bool
+	Description Description
 }
 
 // Types is a sortable slice of Type pointers
@@ -178,33 +458,50 @@ func (t Types) Swap(i, j int) { t[i], t[j] = t[j], t[i] }
 
 // Type documents a constructed type
 type Type struct {
-	Name        string
-	Description string
+	pos  Position
+	Name string
+	// TypeParams name the type parameters of a generic type, written after the
+	// type name, eg: "T" for "box[T]"
+	TypeParams  []string
+	Description Description
 	Methods     Functions
 	Fields      []*Field
 	Operators   []*Operator
+	Extensions  map[string]interface{}
+}
+
+// Pos returns the position of the type name
+func (t *Type) Pos() Position {
+	return t.pos
 }
 
 // Sort sorts a Type pointer's Methods
 func (t *Type) Sort() {
-	sort.Sort(t.Methods)
+	sort.Stable(t.Methods)
 }
 
 // Field is a property of a constructed Type
 type Field struct {
+	pos         Position
 	Name        string
 	Type        string
-	Description string
+	Description Description
 }
 
-// Operator documents boolean operation on a constructed type
+// Operator documents an operation on a constructed type, written as an
+// expression followed by a result type, eg: "duration + time = time"
 type Operator struct {
-	Opr         string
-	Description string
+	pos         Position
+	Opr         string // operator line as written
+	Left        string // type of the left operand, empty for unary operators
+	Symbol      string // operator symbol, eg: "+", "==", "not in"
+	Right       string // type of the right operand
+	Result      string // type the operation evaluates to
+	Description Description
 }
 
 type Example struct {
 	Name        string
-	Description string
+	Description Description
 	Code        string
 }
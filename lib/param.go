@@ -0,0 +1,143 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ParseParam reads a line of a params section:
+//
+//	[*|**]name[?] [type] [= default]
+//
+// "*" marks a param that accepts any number of positional arguments, "**" any
+// number of keyword arguments. "?" or a default value mark an optional param.
+// Types may contain spaces, eg: "geomA [point, line]", and are checked with
+// ParseType
+func ParseParam(text string) (*Param, error) {
+	errorf := func(format string, args ...interface{}) error {
+		return fmt.Errorf("param %q: %s", text, fmt.Sprintf(format, args...))
+	}
+
+	decl, def, hasDefault, err := splitDefault(text)
+	if err != nil {
+		return nil, errorf("%s", err)
+	}
+
+	param := &Param{}
+	switch {
+	case strings.HasPrefix(decl, "***"):
+		return nil, errorf("too many *s, use * for positional or ** for keyword arguments")
+	case strings.HasPrefix(decl, "**"):
+		param.Kwargs = true
+		decl = decl[2:]
+	case strings.HasPrefix(decl, "*"):
+		param.Variadic = true
+		decl = decl[1:]
+	}
+
+	end := strings.IndexFunc(decl, func(r rune) bool { return !isParamNameRune(r) })
+	if end == -1 {
+		end = len(decl)
+	}
+	if param.Name = decl[:end]; param.Name == "" {
+		return nil, errorf("expected a param name")
+	}
+	rest := decl[end:]
+	if strings.HasPrefix(rest, "?") {
+		param.Optional = true
+		rest = rest[1:]
+	}
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return nil, errorf("unexpected %q after param name", rest[:1])
+	}
+
+	param.Type = strings.TrimSpace(rest)
+	if _, err := ParseType(param.Type); err != nil {
+		return nil, errorf("%s", err)
+	}
+
+	if hasDefault {
+		if def == "" {
+			return nil, errorf("expected a default value after \"=\"")
+		}
+		param.Default = def
+		param.Optional = true
+	}
+	if (param.Variadic || param.Kwargs) && param.Optional {
+		return nil, errorf("%s params can't be optional or have a default", param.stars())
+	}
+	return param, nil
+}
+
+// splitDefault separates a param declaration from its default value at the
+// first "=" that isn't quoted or bracketed. Defaults must close every quote
+// & bracket they open
+func splitDefault(text string) (decl, def string, ok bool, err error) {
+	var (
+		quote rune
+		open  []rune
+		eq    = -1
+	)
+	for i, r := range text {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'' || r == '`':
+			quote = r
+		case r == '[' || r == '(' || r == '{':
+			open = append(open, r)
+		case r == ']' || r == ')' || r == '}':
+			if len(open) > 0 {
+				open = open[:len(open)-1]
+			}
+		case r == '=' && len(open) == 0 && eq == -1:
+			eq = i
+			// brackets & quotes are only checked in the default
+			open = nil
+		}
+	}
+	if eq == -1 {
+		return strings.TrimSpace(text), "", false, nil
+	}
+	switch {
+	case quote != 0:
+		return "", "", false, fmt.Errorf("unterminated string in default value")
+	case len(open) > 0:
+		return "", "", false, fmt.Errorf("unclosed %q in default value", string(open[len(open)-1]))
+	}
+	return strings.TrimSpace(text[:eq]), strings.TrimSpace(text[eq+1:]), true, nil
+}
+
+func isParamNameRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// stars returns the prefix that marks a variadic param: "*", "**" or ""
+func (p *Param) stars() string {
+	switch {
+	case p.Kwargs:
+		return "**"
+	case p.Variadic:
+		return "*"
+	}
+	return ""
+}
+
+// Decl writes the param as a params section line, eg: "*args",
+// "limit? int" or "format string = \"RFC3339\""
+func (p *Param) Decl() string {
+	s := p.stars() + p.Name
+	if p.Optional && p.Default == "" {
+		s += "?"
+	}
+	if p.Type != "" {
+		s += " " + p.Type
+	}
+	if p.Default != "" {
+		s += " = " + p.Default
+	}
+	return s
+}
@@ -0,0 +1,122 @@
+package lib
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseParam(t *testing.T) {
+	cases := []struct {
+		text   string
+		expect *Param
+		decl   string
+	}{
+		{"lat", &Param{Name: "lat"}, "lat"},
+		{"lat float", &Param{Name: "lat", Type: "float"}, "lat float"},
+		{"geomA [point, line]", &Param{Name: "geomA", Type: "[point, line]"}, "geomA [point, line]"},
+		{"on_done callable(int) -> bool", &Param{Name: "on_done", Type: "callable(int) -> bool"}, "on_done callable(int) -> bool"},
+		{`format string = "RFC3339"`, &Param{Name: "format", Type: "string", Default: `"RFC3339"`, Optional: true}, `format string = "RFC3339"`},
+		{`sep = " = "`, &Param{Name: "sep", Default: `" = "`, Optional: true}, `sep = " = "`},
+		{"size list[int]=[1, 2]", &Param{Name: "size", Type: "list[int]", Default: "[1, 2]", Optional: true}, "size list[int] = [1, 2]"},
+		{"limit? int", &Param{Name: "limit", Type: "int", Optional: true}, "limit? int"},
+		{"*args", &Param{Name: "args", Variadic: true}, "*args"},
+		{"**kwargs dict[string,any]", &Param{Name: "kwargs", Type: "dict[string,any]", Kwargs: true}, "**kwargs dict[string,any]"},
+	}
+
+	for _, c := range cases {
+		got, err := ParseParam(c.text)
+		if err != nil {
+			t.Errorf("%q: %s", c.text, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got, ignoreUnexported); diff != "" {
+			t.Errorf("%q: param mismatch (-want +got):\n%s", c.text, diff)
+		}
+		if got.Decl() != c.decl {
+			t.Errorf("%q: expected decl %q, got %q", c.text, c.decl, got.Decl())
+		}
+	}
+}
+
+func TestParseParamErrors(t *testing.T) {
+	cases := []struct {
+		text, err string
+	}{
+		{"? int", `param "? int": expected a param name`},
+		{"a-b int", `param "a-b int": unexpected "-" after param name`},
+		{"a list[int", `param "a list[int": type "list[int": expected "]", found end of type at offset 8`},
+		{"a int =", `param "a int =": expected a default value after "="`},
+		{`a string = "abc`, `param "a string = \"abc": unterminated string in default value`},
+		{"a = [1, 2", `param "a = [1, 2": unclosed "[" in default value`},
+		{"***a", `param "***a": too many *s, use * for positional or ** for keyword arguments`},
+		{"*args? int", `param "*args? int": * params can't be optional or have a default`},
+	}
+	for _, c := range cases {
+		_, err := ParseParam(c.text)
+		if err == nil || err.Error() != c.err {
+			t.Errorf("%q: expected error %q, got: %v", c.text, c.err, err)
+		}
+	}
+}
+
+func TestParseParamLines(t *testing.T) {
+	text := `outline: time
+  functions:
+    format(t, layout, *args, **opts) string
+      params:
+        t time
+        layout string = "RFC3339"
+          layout to write the time in
+        *args any
+        **opts
+        zone? [location, string]
+        bad-name int
+          still read as a param`
+
+	docs, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := docs[0].Functions[0].Params
+	expect := []*Param{
+		{Name: "t", Type: "time"},
+		{Name: "layout", Type: "string", Default: `"RFC3339"`, Optional: true, Description: "layout to write the time in"},
+		{Name: "args", Type: "any", Variadic: true},
+		{Name: "opts", Kwargs: true},
+		{Name: "zone", Type: "[location, string]", Optional: true},
+		{Name: "bad-name", Description: "still read as a param"},
+	}
+	if diff := cmp.Diff(expect, params, ignoreUnexported); diff != "" {
+		t.Errorf("params mismatch (-want +got):\n%s", diff)
+	}
+
+	var msgs []string
+	for _, d := range docs[0].Diagnostics() {
+		msgs = append(msgs, d.String())
+	}
+	expectMsgs := []string{`11:9: warning: param "bad-name int": unexpected "-" after param name`}
+	if diff := cmp.Diff(expectMsgs, msgs); diff != "" {
+		t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := Parse(strings.NewReader(text), Strict()); err == nil {
+		t.Error("expected strict parsing to fail on a malformed param")
+	}
+
+	// params survive a round trip through outline text
+	docs[0].Functions[0].Params = params[:5]
+	data, err := MarshalNode(docs, "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(docs, got, ignoreUnexported); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
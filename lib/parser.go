@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"unicode"
 )
 
 // ParseFirst consumes a reader of outline data, creating and returning the first outline document
@@ -28,10 +29,14 @@ func Parse(r io.Reader, opts ...Option) (docs Docs, err error) {
 	if err != nil {
 		return docs, err
 	}
-	p := parser{s: newScanner(r), cfg: cfg}
+	p := parser{s: newScanner(r, cfg.filename, cfg.lineOffset), cfg: cfg}
+	p.s.sections = cfg.sectionSet()
 	for {
 		doc, err := p.read()
 		if doc == nil && err == nil {
+			if err := p.strictErrors(docs); err != nil {
+				return nil, err
+			}
 			return docs, nil
 		}
 		doc.Sort()
@@ -54,39 +59,101 @@ type parser struct {
 	buf struct {
 		tok          Token
 		line, indent int
+		ws           string
+		wsPos        Position
+		blank        bool
 		n            int
 	}
 
-	line   int
-	indent int // indentation level of current line
+	line    int
+	indent  int  // indentation level of current line
+	blank   bool // one or more blank lines precede the current line
+	raw     bool // reading code, where comment lines are read as text
+	desc    bool // reading description text, where "#" lines are text
+	descAt  int  // indentation level of the description being read
+	comment bool // the current line is a comment read as text
+
+	ws    string   // raw leading whitespace of the current line
+	wsPos Position // position of the current line's leading whitespace
+	doc   struct {
+		active      bool
+		indent      indentation
+		mixedIndent bool // mixed indentation has already been reported
+		sawTabs     bool
+		sawSpaces   bool
+		diagnostics []Diagnostic
+	}
 }
 
 func (p *parser) scan() (tok Token) {
+	newlines := 0
 	if p.buf.n > 0 {
+		p.buf.n = 0
 		tok = p.buf.tok
 		p.indent = p.buf.indent
 		p.line = p.buf.line
-		p.buf.n = 0
-		return
+		p.ws = p.buf.ws
+		p.wsPos = p.buf.wsPos
+		p.blank = p.buf.blank
+		if tok.Type != CommentTok {
+			return
+		}
+		if p.textComment(tok) {
+			tok.Type = TextTok
+			return
+		}
+		// a comment read as code is being read outside of code, skip it while
+		// remembering any blank line that preceded it
+		if p.blank {
+			newlines = 1
+		}
 	}
 
 	defer func() {
 		p.buf.tok = tok
 		p.buf.line = p.line
 		p.buf.indent = p.indent
+		p.buf.ws = p.ws
+		p.buf.wsPos = p.wsPos
+		p.buf.blank = p.blank
+		if p.comment {
+			// replayed tokens are skipped if they're no longer read as code
+			p.buf.tok.Type = CommentTok
+		}
 	}()
 
 	for {
 		tok = p.s.Scan()
+		p.comment = false
 		switch tok.Type {
 		case NewlineTok:
 			p.indent = 0
+			p.ws = ""
 			p.line++
+			newlines++
 		case IndentTok:
-			p.indent++
+			p.ws = tok.Text
+			p.wsPos = tok.Pos
 		case eofTok:
 			return
+		case CommentTok:
+			if !p.textComment(tok) {
+				// comment lines don't count as blank lines between the lines
+				// around them, undo the count of the newline that follows
+				newlines--
+				continue
+			}
+			p.comment = true
+			tok.Type = TextTok
+			p.indent = p.level(tok)
+			p.blank = newlines > 1
+			return
 		default:
+			p.indent = p.level(tok)
+			p.blank = newlines > 1
+			if tok.Type == TextTok && !p.raw {
+				p.checkKeyword(tok)
+			}
 			return
 		}
 	}
@@ -96,12 +163,67 @@ func (p *parser) unscan() {
 	p.buf.n = 1
 }
 
+// level calculates the indentation level of the current line, reporting
+// inconsistent indentation within a document
+func (p *parser) level(tok Token) int {
+	tabs := strings.Count(p.ws, "\t")
+	spaces := len(p.ws) - tabs
+
+	if !p.doc.active {
+		// outside of documents fall back to treating two spaces as a level
+		return tabs + spaces/2
+	}
+
+	// "outline:" lines are the base for their own document, and aren't
+	// subject to the indentation rules of any preceding document
+	report := tok.Type != DocumentTok
+	tabs, spaces = p.doc.indent.relative(p.ws)
+
+	if report && !p.doc.mixedIndent {
+		p.doc.sawTabs = p.doc.sawTabs || tabs > 0
+		p.doc.sawSpaces = p.doc.sawSpaces || spaces > 0
+		if p.doc.sawTabs && p.doc.sawSpaces {
+			p.doc.mixedIndent = true
+			p.warnf(p.wsPos, "mixed tabs and spaces in indentation")
+		}
+	}
+
+	level := p.doc.indent.level(tabs, spaces)
+	if unit := p.doc.indent.unit; report && spaces != 0 && spaces%unit != 0 {
+		p.warnf(p.wsPos, "inconsistent indentation: %d spaces is not a multiple of the %d space indent width", spaces, unit)
+	}
+	return level
+}
+
+// beginDocument sets the current line as the base indentation for a new document
+func (p *parser) beginDocument() {
+	p.doc.active = true
+	p.doc.indent.begin(p.ws, p.cfg.indentWidth)
+	p.doc.mixedIndent = false
+	p.doc.sawTabs = false
+	p.doc.sawSpaces = false
+	p.doc.diagnostics = nil
+	p.indent = 0
+}
+
+// endDocument drops document indentation context, returning any diagnostics
+// collected while reading the document
+func (p *parser) endDocument() []Diagnostic {
+	diags := p.doc.diagnostics
+	p.doc.active = false
+	p.doc.diagnostics = nil
+	return diags
+}
+
 func (p *parser) read() (doc *Doc, err error) {
 	for {
 		tok := p.scan()
 		switch tok.Type {
 		case DocumentTok:
-			doc, err = p.readDocument(p.indent)
+			p.beginDocument()
+			doc, err = p.readDocument(p.indent, nil)
+			doc.pos = tok.Pos
+			doc.diagnostics = p.endDocument()
 			return
 		case eofTok:
 			return
@@ -109,21 +231,64 @@ func (p *parser) read() (doc *Doc, err error) {
 	}
 }
 
-func (p *parser) readDocument(baseIndent int) (doc *Doc, err error) {
+// readDocument reads the name & body of a document following an "outline:"
+// token. Documents nested within a parent document are submodules of it
+func (p *parser) readDocument(baseIndent int, parent *Doc) (doc *Doc, err error) {
 	doc = &Doc{cfg: p.cfg}
 	tok := p.scan()
 	if tok.Type == TextTok {
-		doc.Name = tok.Text
+		doc.Name = submoduleName(parent, tok.Text)
 	} else {
 		p.unscan()
 	}
+	err = p.readModule(doc, baseIndent, parent != nil)
+	return
+}
 
+// readModules reads the entries of a "modules:" section, each a submodule
+// name followed by an indented document body
+func (p *parser) readModules(parent *Doc, baseIndent int) (mods Docs, err error) {
+	kw := p.beginSection()
+	defer func() { p.endSection(kw, len(mods)) }()
 	for {
 		tok := p.scan()
-		if p.indent < baseIndent {
+		if p.indent <= baseIndent || tok.Type != TextTok {
 			p.unscan()
 			return
 		}
+		mod := &Doc{cfg: p.cfg, pos: tok.Pos, Name: submoduleName(parent, tok.Text)}
+		mods = append(mods, mod)
+		if err = p.readModule(mod, p.indent, true); err != nil {
+			return
+		}
+	}
+}
+
+// submoduleName qualifies the name of a submodule with the name of its
+// parent, eg: "client" within "http" is "http.client"
+func submoduleName(parent *Doc, name string) string {
+	if parent == nil || parent.Name == "" || strings.HasPrefix(name, parent.Name+".") {
+		return name
+	}
+	return parent.Name + "." + name
+}
+
+// readModule reads the body of a document. nested documents end at the first
+// line that isn't indented beneath them
+func (p *parser) readModule(doc *Doc, baseIndent int, nested bool) (err error) {
+	// stray is unindented text within the document, reported once it's clear the
+	// document continues after it
+	var stray *Token
+	for {
+		tok := p.scan()
+		if p.indent < baseIndent || (nested && p.indent == baseIndent) {
+			p.unscan()
+			return
+		}
+		if stray != nil && tok.Type != eofTok && p.indent > baseIndent {
+			p.strictf(stray.Pos, "text %q isn't indented beneath the document & is ignored", stray.Text)
+			stray = nil
+		}
 
 		switch tok.Type {
 		case DocumentTok:
@@ -132,12 +297,29 @@ func (p *parser) readDocument(baseIndent int) (doc *Doc, err error) {
 				return
 			}
 
-			err = fmt.Errorf("outline documents cannot be nested")
-			return
+			var mod *Doc
+			if mod, err = p.readDocument(p.indent, doc); err != nil {
+				return
+			}
+			mod.pos = tok.Pos
+			doc.Submodules = append(doc.Submodules, mod)
+		case ModulesTok:
+			var mods Docs
+			if mods, err = p.readModules(doc, p.indent); err != nil {
+				return
+			}
+			doc.Submodules = append(doc.Submodules, mods...)
 		case PathTok:
 			if doc.Path, err = p.readMultilineText(p.indent); err != nil {
 				return
 			}
+		case IncludeTok:
+			doc.Includes = append(doc.Includes, p.readList(p.indent)...)
+		case ImportTok:
+			for _, tok := range p.readListTokens(p.indent) {
+				doc.Imports = append(doc.Imports, tok.Text)
+				doc.addImport(tok.Text, tok.Pos)
+			}
 		case FunctionsTok:
 			if doc.Functions, err = p.readFunctions(doc.Name, p.indent); err != nil {
 				return
@@ -146,17 +328,24 @@ func (p *parser) readDocument(baseIndent int) (doc *Doc, err error) {
 			if doc.Types, err = p.readTypes(p.indent); err != nil {
 				return
 			}
+		case SectionTok:
+			p.readSection(tok, InDocument, &doc.Extensions)
 		case TextTok:
 			// only read descriptions when indented
 			if p.indent > baseIndent {
 				p.unscan()
-				text, err := p.readMultilineText(p.indent)
-				if err != nil {
-					return doc, err
+				var text Description
+				if text, err = p.readDescription(p.indent); err != nil {
+					return
 				}
 				doc.Description = text
+			} else if stray == nil {
+				stray = &tok
 			}
 		default:
+			if p.misplaced(tok, "a document", PathTok, ImportTok, IncludeTok, FunctionsTok, TypesTok, ModulesTok) {
+				continue
+			}
 			p.unscan()
 			return
 		}
@@ -164,6 +353,8 @@ func (p *parser) readDocument(baseIndent int) (doc *Doc, err error) {
 }
 
 func (p *parser) readFunctions(receiver string, baseIndent int) (funcs []*Function, err error) {
+	kw := p.beginSection()
+	defer func() { p.endSection(kw, len(funcs)) }()
 	for {
 		var fn *Function
 		if fn, err = p.readFunction(receiver, baseIndent+1); err != nil || fn == nil {
@@ -187,7 +378,7 @@ func (p *parser) readFunction(receiver string, baseIndent int) (fn *Function, er
 		funcName = tok.Text[:pos]
 	}
 
-	fn = &Function{FuncName: funcName, Receiver: receiver, Signature: tok.Text}
+	fn = &Function{pos: tok.Pos, FuncName: funcName, Receiver: receiver, Signature: tok.Text}
 	for {
 		tok := p.scan()
 		if p.indent <= baseIndent {
@@ -208,12 +399,17 @@ func (p *parser) readFunction(receiver string, baseIndent int) (fn *Function, er
 			if fn.Examples, err = p.readExamples(p.indent); err != nil {
 				return fn, err
 			}
+		case SectionTok:
+			p.readSection(tok, InFunction, &fn.Extensions)
 		case TextTok:
 			p.unscan()
-			if fn.Description, err = p.readTextBlock(p.indent); err != nil {
+			if fn.Description, err = p.readDescription(p.indent); err != nil {
 				return
 			}
 		default:
+			if p.misplaced(tok, "a function", ParamsTok, ReturnTok, ExamplesTok) {
+				continue
+			}
 			p.unscan()
 			return
 		}
@@ -221,6 +417,8 @@ func (p *parser) readFunction(receiver string, baseIndent int) (fn *Function, er
 }
 
 func (p *parser) readParams(baseIndent int) (params []*Param, err error) {
+	kw := p.beginSection()
+	defer func() { p.endSection(kw, len(params)) }()
 	for {
 		var param *Param
 		if param, err = p.readParam(baseIndent + 1); err != nil || param == nil {
@@ -237,22 +435,20 @@ func (p *parser) readParam(baseIndent int) (param *Param, err error) {
 		return
 	}
 
-	spl := strings.Split(tok.Text, " ")
-	switch len(spl) {
-	default:
-		param = &Param{Name: tok.Text}
-	case 2:
-		param = &Param{
-			Name: spl[0],
-			Type: spl[1],
-		}
+	if param, err = ParseParam(tok.Text); err != nil {
+		// keep the param so its description isn't read as something else
+		p.reportf(tok.Pos, "%s", err)
+		param, err = &Param{Name: strings.Fields(tok.Text)[0]}, nil
 	}
+	param.pos = tok.Pos
 
-	param.Description, err = p.readMultilineText(baseIndent + 1)
+	param.Description, err = p.readDescription(baseIndent + 1)
 	return
 }
 
 func (p *parser) readTypes(baseIndent int) (types []*Type, err error) {
+	kw := p.beginSection()
+	defer func() { p.endSection(kw, len(types)) }()
 	for {
 		var t *Type
 		if t, err = p.readType(baseIndent + 1); err != nil || t == nil {
@@ -270,7 +466,8 @@ func (p *parser) readType(baseIndent int) (t *Type, err error) {
 		return
 	}
 
-	t = &Type{Name: tok.Text}
+	t = &Type{pos: tok.Pos}
+	t.Name, t.TypeParams = splitTypeParams(tok.Text)
 
 	for {
 		tok = p.scan()
@@ -292,12 +489,17 @@ func (p *parser) readType(baseIndent int) (t *Type, err error) {
 			if t.Operators, err = p.readOperators(p.indent); err != nil {
 				return
 			}
+		case SectionTok:
+			p.readSection(tok, InType, &t.Extensions)
 		case TextTok:
 			p.unscan()
-			if t.Description, err = p.readTextBlock(p.indent); err != nil {
+			if t.Description, err = p.readDescription(p.indent); err != nil {
 				return
 			}
 		default:
+			if p.misplaced(tok, "a type", FieldsTok, MethodsTok, OperatorsTok) {
+				continue
+			}
 			err = fmt.Errorf("unexpexted token: %s: %s %d %d", tok.Type, tok.Text, p.indent, baseIndent)
 			return
 		}
@@ -305,6 +507,8 @@ func (p *parser) readType(baseIndent int) (t *Type, err error) {
 }
 
 func (p *parser) readFields(baseIndent int) (fields []*Field, err error) {
+	kw := p.beginSection()
+	defer func() { p.endSection(kw, len(fields)) }()
 	for {
 		var f *Field
 		if f, err = p.readField(baseIndent + 1); err != nil || f == nil {
@@ -321,22 +525,21 @@ func (p *parser) readField(baseIndent int) (field *Field, err error) {
 		return
 	}
 
-	spl := strings.Split(tok.Text, " ")
-	switch len(spl) {
-	case 1:
-		field = &Field{Name: tok.Text}
-	case 2:
-		field = &Field{
-			Name: spl[0],
-			Type: spl[1],
-		}
+	// the type is everything after the name, & may contain spaces:
+	// "handler callable(request) -> response"
+	field = &Field{Name: tok.Text, pos: tok.Pos}
+	if i := strings.IndexAny(tok.Text, " \t"); i != -1 {
+		field.Name, field.Type = tok.Text[:i], strings.TrimSpace(tok.Text[i+1:])
 	}
+	p.checkType(tok.Pos, "field "+field.Name, field.Type)
 
-	field.Description, err = p.readMultilineText(baseIndent + 1)
+	field.Description, err = p.readDescription(baseIndent + 1)
 	return
 }
 
 func (p *parser) readOperators(baseIndent int) (ops []*Operator, err error) {
+	kw := p.beginSection()
+	defer func() { p.endSection(kw, len(ops)) }()
 	for {
 		var o *Operator
 		if o, err = p.readOperator(baseIndent + 1); err != nil || o == nil {
@@ -352,7 +555,12 @@ func (p *parser) readOperator(baseIndent int) (op *Operator, err error) {
 		p.unscan()
 		return
 	}
-	op = &Operator{Opr: tok.Text}
+	var perr error
+	if op, perr = parseOperator(tok.Text); perr != nil {
+		p.warnf(tok.Pos, "%s", perr)
+	}
+	op.pos = tok.Pos
+	op.Description, err = p.readDescription(baseIndent + 1)
 	return
 }
 
@@ -372,7 +580,90 @@ func (p *parser) readMultilineText(baseIndent int) (str string, err error) {
 	}
 }
 
+// readList reads one entry per line, starting with any text on the same line as
+// the keyword that precedes the list, eg: "include: a.outline" or:
+//
+//	include:
+//	  a.outline
+//	  b.outline
+func (p *parser) readList(baseIndent int) (items []string) {
+	for _, tok := range p.readListTokens(baseIndent) {
+		items = append(items, tok.Text)
+	}
+	return items
+}
+
+// readListTokens reads the entries of a list as tokens, keeping the position
+// of each entry
+func (p *parser) readListTokens(baseIndent int) (toks []Token) {
+	line := p.line
+	for {
+		tok := p.scan()
+		if tok.Type != TextTok || (p.line != line && p.indent <= baseIndent) {
+			p.unscan()
+			return
+		}
+		toks = append(toks, tok)
+	}
+}
+
+// readDescription reads markdown description text. Lines of a paragraph are
+// joined with spaces, blank lines start a new paragraph, and list items,
+// headings & fenced code blocks keep their line breaks. Once a description has begun,
+// lines starting with "#" are markdown headings rather than comments
+func (p *parser) readDescription(baseIndent int) (Description, error) {
+	var (
+		b       strings.Builder
+		fence   bool   // reading the body of a fenced code block
+		fenceWS string // leading whitespace of the opening fence
+		heading bool   // the previous line is a heading
+	)
+
+	p.descAt = baseIndent
+	defer func() { p.raw, p.desc = false, false }()
+	for {
+		tok := p.scan()
+		if p.indent < baseIndent || tok.Type != TextTok {
+			p.unscan()
+			return Description(b.String()), nil
+		}
+
+		isFence := strings.HasPrefix(tok.Text, "```")
+		switch {
+		case b.Len() == 0:
+		case p.blank:
+			b.WriteString("\n\n")
+		case fence, isFence, heading, isListItem(tok.Text), isHeading(tok.Text):
+			b.WriteString("\n")
+		default:
+			b.WriteString(" ")
+		}
+
+		if fence && !isFence {
+			// keep code indentation relative to the opening fence
+			b.WriteString(strings.TrimPrefix(p.ws, fenceWS))
+		}
+		b.WriteString(tok.Text)
+		p.desc = true
+		heading = !fence && isHeading(tok.Text)
+
+		if isFence {
+			fence = !fence
+			fenceWS = p.ws
+			p.raw = fence
+		}
+	}
+}
+
+// textComment reports whether a comment line is read as text: any comment
+// within code, & "#" lines within descriptions
+func (p *parser) textComment(tok Token) bool {
+	return p.raw || (p.desc && strings.HasPrefix(tok.Text, "#"))
+}
+
 func (p *parser) readTextBlock(baseIndent int) (str string, err error) {
+	p.raw = true
+	defer func() { p.raw = false }()
 	for {
 		tok := p.scan()
 		if p.indent < baseIndent || tok.Type != TextTok {
@@ -389,6 +680,8 @@ func (p *parser) readTextBlock(baseIndent int) (str string, err error) {
 }
 
 func (p *parser) readExamples(baseIndent int) (egs []*Example, err error) {
+	kw := p.beginSection()
+	defer func() { p.endSection(kw, len(egs)) }()
 	for {
 		var eg *Example
 		if eg, err = p.readExample(baseIndent + 1); err != nil || eg == nil {
@@ -421,16 +714,166 @@ func (p *parser) readExample(baseIndent int) (eg *Example, err error) {
 			}
 		case TextTok:
 			p.unscan()
-			if eg.Description, err = p.readTextBlock(p.indent); err != nil {
+			if eg.Description, err = p.readDescription(p.indent); err != nil {
 				return eg, err
 			}
 		default:
+			if p.misplaced(tok, "an example", CodeTok) {
+				continue
+			}
 			p.unscan()
 			return eg, nil
 		}
 	}
 }
 
+// beginSection is called after scanning a section keyword that lists
+// elements, returning the keyword token. Strict parsing reports text that
+// follows the keyword on the same line, which would otherwise be read as part
+// of the element that contains the section
+func (p *parser) beginSection() (kw Token) {
+	kw = p.buf.tok
+	if !p.cfg.strict {
+		return kw
+	}
+	line := p.line
+	if tok := p.scan(); tok.Type == TextTok && p.line == line {
+		p.strictf(tok.Pos, "unexpected text %q after %s:, write entries on lines indented beneath it", tok.Text, kw.Type)
+		return kw
+	}
+	p.unscan()
+	return kw
+}
+
+// endSection reports sections without any entries
+func (p *parser) endSection(kw Token, entries int) {
+	if entries == 0 {
+		p.strictf(kw.Pos, "%s: has no entries, indent them beneath it", kw.Type)
+	}
+}
+
+// misplaced reports a section keyword that isn't allowed in the element being
+// read, skipping the section when parsing strictly. It returns true if the
+// section was skipped
+func (p *parser) misplaced(tok Token, where string, allowed ...TokenType) bool {
+	if !p.cfg.strict || tok.Type <= KeywordBegin || tok.Type >= KeywordEnd || tok.Type == DocumentTok {
+		return false
+	}
+	names := make([]string, len(allowed))
+	for i, t := range allowed {
+		names[i] = t.String() + ":"
+	}
+	p.strictf(tok.Pos, "%s: isn't allowed in %s, expected %s", tok.Text, where, orList(names))
+	p.skip(p.indent)
+	return true
+}
+
+// skip reads past the rest of the current line & the lines indented beneath it
+func (p *parser) skip(indent int) {
+	line := p.line
+	for {
+		tok := p.scan()
+		if tok.Type == eofTok || (p.line != line && p.indent <= indent) {
+			p.unscan()
+			return
+		}
+	}
+}
+
+// checkType reports a type that isn't a valid type expression
+func (p *parser) checkType(pos Position, context, typ string) {
+	if _, err := ParseType(typ); err != nil {
+		p.reportf(pos, "%s: %s", context, err)
+	}
+}
+
+// checkKeyword reports text that's written like a section keyword, eg:
+// "parms:", but doesn't match one. Lines within description text, like
+// "Usage:", aren't where sections are written & aren't checked
+func (p *parser) checkKeyword(tok Token) {
+	if !p.cfg.strict || !p.doc.active || p.indent == 0 || !strings.HasSuffix(tok.Text, ":") {
+		return
+	}
+	if p.desc && p.indent >= p.descAt {
+		return
+	}
+	word := strings.TrimSuffix(tok.Text, ":")
+	for _, r := range word {
+		if !(r == '_' || unicode.IsLetter(r)) {
+			return
+		}
+	}
+	names := make([]string, 0, len(p.s.sections))
+	for name := range p.s.sections {
+		names = append(names, name)
+	}
+	if kw := suggestKeyword(word, names...); kw != "" {
+		p.strictf(tok.Pos, "unknown keyword %q, did you mean %q?", tok.Text, kw+":")
+		p.doc.diagnostics[len(p.doc.diagnostics)-1].Suggestion = kw + ":"
+		return
+	}
+	p.strictf(tok.Pos, "unknown keyword %q", tok.Text)
+}
+
+// strictErrors collects the error diagnostics of strictly parsed documents
+func (p *parser) strictErrors(docs Docs) error {
+	if !p.cfg.strict {
+		return nil
+	}
+	var errs Diagnostics
+	for _, doc := range docs {
+		for _, d := range doc.diagnostics {
+			if d.Severity == Error {
+				errs = append(errs, d)
+			}
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// orList joins a list of alternatives, eg: "a, b or c"
+func orList(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " or " + items[len(items)-1]
+}
+
 func (p *parser) errorf(format string, args ...interface{}) error {
 	return fmt.Errorf(format, args...)
 }
+
+// strictf records an error diagnostic for the current document when parsing
+// strictly
+func (p *parser) strictf(pos Position, format string, args ...interface{}) {
+	if !p.cfg.strict {
+		return
+	}
+	p.doc.diagnostics = append(p.doc.diagnostics, Diagnostic{
+		Pos:      pos,
+		Severity: Error,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// reportf records an error diagnostic when parsing strictly, and a warning
+// otherwise
+func (p *parser) reportf(pos Position, format string, args ...interface{}) {
+	if p.cfg.strict {
+		p.strictf(pos, format, args...)
+		return
+	}
+	p.warnf(pos, format, args...)
+}
+
+// warnf records a warning diagnostic for the current document
+func (p *parser) warnf(pos Position, format string, args ...interface{}) {
+	p.doc.diagnostics = append(p.doc.diagnostics, Diagnostic{
+		Pos:      pos,
+		Severity: Warning,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
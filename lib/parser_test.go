@@ -2,6 +2,7 @@ package lib
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -11,6 +12,10 @@ import (
 
 var differ = diffmatchpatch.New()
 
+// ignoreUnexported skips parse state like positions & diagnostics when
+// comparing documents
+var ignoreUnexported = cmpopts.IgnoreUnexported(Doc{}, Function{}, Param{}, Type{}, Field{}, Operator{})
+
 const twoFuncsTabs = `outline: twoFuncs
 	path: twoFuncs
 	functions:
@@ -120,16 +125,16 @@ var time = &Doc{
 				{Name: "seconds", Description: "number of seconds starting at zero"},
 			},
 			Operators: []*Operator{
-				{Opr: "duration - time = duration"},
-				{Opr: "duration + time = time"},
-				{Opr: "duration == duration = boolean"},
-				{Opr: "duration < duration = booleans"},
+				{Opr: "duration - time = duration", Left: "duration", Symbol: "-", Right: "time", Result: "duration"},
+				{Opr: "duration + time = time", Left: "duration", Symbol: "+", Right: "time", Result: "time"},
+				{Opr: "duration == duration = boolean", Left: "duration", Symbol: "==", Right: "duration", Result: "boolean"},
+				{Opr: "duration < duration = booleans", Left: "duration", Symbol: "<", Right: "duration", Result: "booleans"},
 			},
 		},
 		{Name: "time",
 			Operators: []*Operator{
-				{Opr: "time == time = boolean"},
-				{Opr: "time < time = boolean"},
+				{Opr: "time == time = boolean", Left: "time", Symbol: "==", Right: "time", Result: "boolean"},
+				{Opr: "time < time = boolean", Left: "time", Symbol: "<", Right: "time", Result: "boolean"},
 			},
 		},
 	},
@@ -300,7 +305,7 @@ func TestParse(t *testing.T) {
 				t.Fatal("doc returned nil")
 			}
 
-			if diff := cmp.Diff(c.exp, got, cmpopts.IgnoreUnexported(Doc{})); diff != "" {
+			if diff := cmp.Diff(c.exp, got, ignoreUnexported); diff != "" {
 				t.Errorf("result mismatch (-want +got):\n%s", diff)
 			}
 
@@ -312,3 +317,425 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+const fourSpaces = `outline: fourSpaces
+    functions:
+        sum(a,b int) int
+            add two things together
+            params:
+                a int
+                b int`
+
+var fourSpacesDoc = &Doc{
+	Name: "fourSpaces",
+	Functions: []*Function{
+		{FuncName: "sum",
+			Receiver:    "fourSpaces",
+			Signature:   "sum(a,b int) int",
+			Description: "add two things together",
+			Params: []*Param{
+				{Name: "a", Type: "int"},
+				{Name: "b", Type: "int"},
+			},
+		},
+	},
+}
+
+func TestParseIndentation(t *testing.T) {
+	cases := []struct {
+		name  string
+		in    string
+		opts  []Option
+		exp   *Doc
+		diags []string
+	}{
+		{"four_spaces", fourSpaces, nil, fourSpacesDoc, nil},
+		{"four_spaces_option", fourSpaces, []Option{IndentWidth(4)}, fourSpacesDoc, nil},
+		{"indented_document", "  outline: fourSpaces\n      functions:\n          sum(a,b int) int\n              add two things together\n              params:\n                  a int\n                  b int", nil, fourSpacesDoc, nil},
+		{"mixed", twoFuncsSpaces, nil, twoFuncs, []string{
+			"3:1: warning: mixed tabs and spaces in indentation",
+		}},
+		{"inconsistent", "outline: fourSpaces\n    functions:\n        sum(a,b int) int\n           add two things together\n            params:\n                a int\n                b int", nil, fourSpacesDoc, []string{
+			"4:1: warning: inconsistent indentation: 11 spaces is not a multiple of the 4 space indent width",
+		}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := ParseFirst(bytes.NewBufferString(c.in), c.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(c.exp, got, ignoreUnexported); diff != "" {
+				t.Errorf("result mismatch (-want +got):\n%s", diff)
+			}
+
+			var diags []string
+			for _, d := range got.Diagnostics() {
+				diags = append(diags, d.String())
+			}
+			if diff := cmp.Diff(c.diags, diags); diff != "" {
+				t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+
+	if _, err := Parse(bytes.NewBufferString(fourSpaces), IndentWidth(0)); err == nil {
+		t.Error("expected zero indent width to error")
+	}
+}
+
+const richDescriptionsText = `outline: rich
+  rich has descriptions that span
+  more than one line.
+
+  they have paragraphs, and lists:
+  - one
+  - two
+    continued
+  functions:
+    render(tmpl string) string
+      render a template:
+
+      ` + "```python" + `
+      if ok:
+        render("a")
+      ` + "```" + `
+      params:
+        tmpl string
+          the template to render.
+
+          must not be empty`
+
+var richDescriptions = &Doc{
+	Name:        "rich",
+	Description: "rich has descriptions that span more than one line.\n\nthey have paragraphs, and lists:\n- one\n- two continued",
+	Functions: []*Function{
+		{FuncName: "render",
+			Receiver:    "rich",
+			Signature:   "render(tmpl string) string",
+			Description: "render a template:\n\n```python\nif ok:\n  render(\"a\")\n```",
+			Params: []*Param{
+				{Name: "tmpl", Type: "string", Description: "the template to render.\n\nmust not be empty"},
+			},
+		},
+	},
+}
+
+func TestParseDescriptions(t *testing.T) {
+	got, err := ParseFirst(bytes.NewBufferString(richDescriptionsText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(richDescriptions, got, ignoreUnexported); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	// formatted output must parse back to the same document
+	data, err := got.MarshalIndent(0, "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := ParseFirst(bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(got.Description, again.Description); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+	if diff := cmp.Diff(got.Functions[0].Description, again.Functions[0].Description); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+const operatorsText = `outline: ops
+  types:
+    set
+      operators:
+        set | set = set
+          union of two sets
+        -set = set
+        string not in set = bool
+          reports whether a string is absent
+          from the set
+        dict[string, set] | set = dict[string, set]
+        set ?? set`
+
+func TestParseOperators(t *testing.T) {
+	got, err := ParseFirst(bytes.NewBufferString(operatorsText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expect := []*Operator{
+		{Opr: "set | set = set", Left: "set", Symbol: "|", Right: "set", Result: "set", Description: "union of two sets"},
+		{Opr: "-set = set", Symbol: "-", Right: "set", Result: "set"},
+		{Opr: "string not in set = bool", Left: "string", Symbol: "not in", Right: "set", Result: "bool", Description: "reports whether a string is absent from the set"},
+		{Opr: "dict[string, set] | set = dict[string, set]", Left: "dict[string, set]", Symbol: "|", Right: "set", Result: "dict[string, set]"},
+		{Opr: "set ?? set"},
+	}
+	if diff := cmp.Diff(expect, got.Types[0].Operators, ignoreUnexported); diff != "" {
+		t.Errorf("result mismatch (-want +got):\n%s", diff)
+	}
+
+	tokens := []string{"PIPE", "MINUS", "NOT_IN", "PIPE", ""}
+	for i, op := range got.Types[0].Operators {
+		if op.SyntaxToken() != tokens[i] {
+			t.Errorf("operator %d syntax token mismatch. expected: %q, got: %q", i, tokens[i], op.SyntaxToken())
+		}
+	}
+
+	if len(got.Diagnostics()) != 1 {
+		t.Errorf("expected one diagnostic for a malformed operator, got: %v", got.Diagnostics())
+	}
+}
+
+const commentsText = `outline: notes
+  # comments are skipped
+  notes about things
+  // either comment marker works
+
+  second paragraph
+  functions:
+    # before a function
+    add(a, b int) int
+      ` + "```" + `
+      # kept in code
+      ` + "```" + `
+      examples:
+        basic
+          code:
+            # kept in code
+            add(1, 2)`
+
+func TestParseComments(t *testing.T) {
+	got, err := ParseFirst(bytes.NewBufferString(commentsText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Description.String() != "notes about things\n\nsecond paragraph" {
+		t.Errorf("description mismatch. got: %q", got.Description.String())
+	}
+	if len(got.Functions) != 1 {
+		t.Fatalf("expected one function, got: %d", len(got.Functions))
+	}
+	fn := got.Functions[0]
+	if fn.Description.String() != "```\n# kept in code\n```" {
+		t.Errorf("function description mismatch. got: %q", fn.Description.String())
+	}
+	if fn.Examples[0].Code != "# kept in code\nadd(1, 2)" {
+		t.Errorf("example code mismatch. got: %q", fn.Examples[0].Code)
+	}
+}
+
+func TestParseDescriptionHeadings(t *testing.T) {
+	text := `outline: geo
+  # a comment before the description
+  geo does geography
+
+  ## Details
+  distances are in meters
+  // a comment within the description
+  functions:
+    point(x, y)
+      make a point
+      # Notes
+      points are immutable
+      #immutable
+      params:
+        x float`
+
+	got, err := ParseFirst(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expect := "geo does geography\n\n## Details\ndistances are in meters"; got.Description.String() != expect {
+		t.Errorf("description mismatch. want: %q got: %q", expect, got.Description.String())
+	}
+	fn := got.Functions[0]
+	if expect := "make a point\n# Notes\npoints are immutable #immutable"; fn.Description.String() != expect {
+		t.Errorf("function description mismatch. want: %q got: %q", expect, fn.Description.String())
+	}
+	if len(fn.Params) != 1 {
+		t.Errorf("expected one param, got: %d", len(fn.Params))
+	}
+}
+
+const modulesText = `outline: http
+  http speaks http
+  functions:
+    get(url string) response
+  modules:
+    client
+      http clients
+      types:
+        conn
+          fields:
+            resp response
+  outline: server
+    functions:
+      listen(addr string)
+    modules:
+      mux
+        functions:
+          handle(path string)
+  types:
+    response
+
+outline: re
+  functions:
+    compile(pattern string) regex`
+
+func TestParseSubmodules(t *testing.T) {
+	docs, err := Parse(bytes.NewBufferString(modulesText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got: %d", len(docs))
+	}
+
+	var names []string
+	for _, doc := range docs.Modules() {
+		names = append(names, doc.Name)
+	}
+	expect := []string{"http", "http.client", "http.server", "http.server.mux", "re"}
+	if diff := cmp.Diff(expect, names); diff != "" {
+		t.Errorf("module names mismatch (-want +got):\n%s", diff)
+	}
+
+	http := docs[0]
+	if len(http.Types) != 1 || http.Types[0].Name != "response" {
+		t.Errorf("expected types after submodules to belong to http, got: %#v", http.Types)
+	}
+	client := http.Submodules[0]
+	if client.Description != "http clients" || len(client.Types) != 1 || client.Pos().Line != 6 {
+		t.Errorf("unexpected client module: %#v", client)
+	}
+	mux := http.Submodules[1].Submodules[0]
+	if len(mux.Functions) != 1 || mux.Functions[0].Receiver != "http.server.mux" {
+		t.Errorf("unexpected mux module: %#v", mux)
+	}
+
+	// submodules are written in modules sections, & survive a round trip
+	data, err := MarshalNode(docs, "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "  modules:\n    client\n") {
+		t.Errorf("expected submodules in a modules section, got:\n%s", data)
+	}
+	got, err := Parse(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(docs, got, ignoreUnexported); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+	cases := []struct {
+		text   string
+		errors []string
+	}{
+		{timeSpaces, []string{
+			"35:7: error: fields: has no entries, indent them beneath it",
+		}},
+		{commentsText, nil},
+		{`outline: typo
+  functions:
+    add(a, b int) int
+      parms:
+        a int`, []string{
+			`4:7: error: unknown keyword "parms:", did you mean "params:"?`,
+		}},
+		{`outline: typo
+  types:
+    set
+      method:
+        add(x)`, []string{
+			`4:7: error: unknown keyword "method:", did you mean "methods:"?`,
+		}},
+		{`outline: cli
+  cli runs commands
+  Usage:
+    cli [command]
+  types:
+    cmd
+      a command
+      Example:
+        cmd run
+  functons:
+    run(cmd)`, []string{
+			`10:3: error: unknown keyword "functons:", did you mean "functions:"?`,
+		}},
+		{`outline: misplaced
+  functions:
+    add(a, b int) int
+      fields:
+        a int
+      return: int`, []string{
+			`4:7: error: fields: isn't allowed in a function, expected params:, return: or examples:`,
+		}},
+		{`outline: stray
+  functions: add
+    add(a, b int) int
+  types:
+
+stray text
+  types:
+    set`, []string{
+			`2:13: error: unexpected text "add" after functions:, write entries on lines indented beneath it`,
+			`4:3: error: types: has no entries, indent them beneath it`,
+			`6:1: error: text "stray text" isn't indented beneath the document & is ignored`,
+		}},
+	}
+
+	for i, c := range cases {
+		docs, err := Parse(bytes.NewBufferString(c.text))
+		if err != nil {
+			t.Fatalf("case %d: non-strict parsing shouldn't fail: %s", i, err)
+		}
+		if len(docs) == 0 {
+			t.Fatalf("case %d: expected documents", i)
+		}
+
+		_, err = Parse(bytes.NewBufferString(c.text), Strict())
+		var got []string
+		if err != nil {
+			diags, ok := err.(Diagnostics)
+			if !ok {
+				t.Fatalf("case %d: expected Diagnostics error, got: %#v", i, err)
+			}
+			for _, d := range diags {
+				got = append(got, d.String())
+				if strings.Contains(d.Message, "did you mean") && d.Suggestion == "" {
+					t.Errorf("case %d: expected a suggestion with %q", i, d.Message)
+				}
+			}
+		}
+		if diff := cmp.Diff(c.errors, got); diff != "" {
+			t.Errorf("case %d: errors mismatch (-want +got):\n%s", i, diff)
+		}
+	}
+}
+
+func TestSuggestKeyword(t *testing.T) {
+	cases := map[string]string{
+		"parms":    "params",
+		"method":   "methods",
+		"retrun":   "return",
+		"Examples": "examples",
+		"exmaple":  "examples",
+		"notes":    "",
+		"x":        "",
+	}
+	for word, expect := range cases {
+		if got := suggestKeyword(word); got != expect {
+			t.Errorf("%q: expected suggestion %q, got %q", word, expect, got)
+		}
+	}
+}
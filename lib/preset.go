@@ -0,0 +1,150 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// Preset is a named, built-in documentation template. Presets execute
+// against Docs
+type Preset struct {
+	Name        string
+	Description string
+	// Ext is the file extension of rendered output, eg: ".md"
+	Ext string
+	// Text is the template source. Presets define named blocks that templates
+	// loaded on top of the preset can override
+	Text string
+}
+
+// presets is the registry of built-in templates
+var presets = map[string]*Preset{}
+
+// RegisterPreset adds a preset to the registry, replacing any preset with the
+// same name
+func RegisterPreset(p *Preset) {
+	presets[p.Name] = p
+}
+
+// LookupPreset gets a registered preset by name
+func LookupPreset(name string) (*Preset, error) {
+	if p, ok := presets[name]; ok {
+		return p, nil
+	}
+
+	var names []string
+	for _, p := range Presets() {
+		names = append(names, p.Name)
+	}
+	return nil, fmt.Errorf("unknown format %q. available formats: %s", name, strings.Join(names, ", "))
+}
+
+// Presets lists registered presets sorted by name
+func Presets() []*Preset {
+	list := make([]*Preset, 0, len(presets))
+	for _, p := range presets {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// templateExts are the file extensions read from template directories
+var templateExts = map[string]bool{
+	".tmpl":   true,
+	".tpl":    true,
+	".gotmpl": true,
+}
+
+// LoadTemplate parses a preset, then layers template files on top of it.
+// paths may name files or directories. Directories are searched for files
+// ending in .tmpl, .tpl or .gotmpl, read in name order.
+//
+// Blocks defined in loaded files replace blocks of the same name in the
+// preset, so a file that only redefines "mdFn" changes how functions are
+// written without copying the rest of the preset. The returned template
+// executes the last loaded file that has content outside of define blocks,
+// falling back to the preset when no file does
+func LoadTemplate(preset string, paths []string, funcs template.FuncMap) (*template.Template, error) {
+	p, err := LookupPreset(preset)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := template.New(p.Name).Funcs(funcs).Parse(p.Text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s preset: %s", p.Name, err)
+	}
+
+	filenames, err := templateFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	root := t
+	for _, filename := range filenames {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+
+		name := filepath.Base(filename)
+		ft, err := t.New(name).Parse(string(data))
+		if err != nil {
+			return nil, err
+		}
+		if hasContent(ft) {
+			root = ft
+		}
+	}
+
+	return root, nil
+}
+
+// templateFiles expands directories in a list of paths to the template files
+// they contain
+func templateFiles(paths []string) ([]string, error) {
+	var filenames []string
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			filenames = append(filenames, path)
+			continue
+		}
+
+		infos, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if !info.IsDir() && templateExts[filepath.Ext(info.Name())] {
+				filenames = append(filenames, filepath.Join(path, info.Name()))
+			}
+		}
+	}
+	return filenames, nil
+}
+
+// hasContent reports whether a template writes anything outside of define
+// blocks, ignoring whitespace
+func hasContent(t *template.Template) bool {
+	if t.Tree == nil || t.Tree.Root == nil {
+		return false
+	}
+	for _, n := range t.Tree.Root.Nodes {
+		if text, ok := n.(*parse.TextNode); ok && strings.TrimSpace(string(text.Text)) == "" {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package lib
+
+func init() {
+	RegisterPreset(&Preset{
+		Name:        "asciidoc",
+		Description: "asciidoc, one section per document",
+		Ext:         ".adoc",
+		Text: `{{- define "adocFn" -}}
+[[{{ anchor . }}]]
+==== ` + "`{{ .Signature }}`" + `
+{{ if ne .Description "" }}
+{{ .Description }}
+{{ end -}}
+{{ if gt (len .Params) 0 }}
+.parameters
+[cols="1,1,3"]
+|===
+|name |type |description
+{{ range .Params }}
+|{{ replace "|" "\\|" .Name }} |{{ replace "|" "\\|" .Type }} |{{ replace "|" "\\|" (inline .Description) }}
+{{- end }}
+|===
+{{ end }}
+{{ end -}}
+
+{{- define "adocType" -}}
+[[{{ anchor . }}]]
+=== ` + "`{{ .Name }}`" + `
+{{ if ne .Description "" }}
+{{ .Description }}
+{{ end -}}
+{{ if gt (len .Fields) 0 }}
+.fields
+[cols="1,1,3"]
+|===
+|name |type |description
+{{ range .Fields }}
+|{{ replace "|" "\\|" .Name }} |{{ replace "|" "\\|" .Type }} |{{ replace "|" "\\|" (inline .Description) }}
+{{- end }}
+|===
+{{ end -}}
+{{ range .Methods }}
+{{ template "adocFn" . }}
+{{- end -}}
+{{ if gt (len .Operators) 0 }}
+.operators
+[cols="2,1,3"]
+|===
+|operator |result |description
+{{ range .Operators }}
+|` + "`{{ replace \"|\" \"\\\\|\" .Expr }}`" + ` |` + "`{{ .Result }}`" + ` |{{ replace "|" "\\|" (inline .Description) }}
+{{- end }}
+|===
+{{ end }}
+{{ end -}}
+
+{{- define "index" -}}
+= Index
+
+{{ range . -}}
+* xref:{{ filename . }}#{{ anchor . }}[{{ .Name }}]{{ if ne .Description "" }}: {{ inline .Description }}{{ end }}
+{{ end -}}
+{{ end -}}
+
+{{- define "adocDoc" -}}
+[[{{ anchor . }}]]
+== {{ .Name }}
+{{ if ne .Description "" }}
+{{ .Description }}
+{{ end -}}
+{{ if gt (len .Functions) 0 }}
+=== Functions
+
+{{ range .Functions }}{{ template "adocFn" . }}{{ end -}}
+{{ end -}}
+{{ if gt (len .Types) 0 }}
+{{ range .Types }}{{ template "adocType" . }}{{ end -}}
+{{ end -}}
+{{ end -}}
+
+{{- range .Modules }}{{ template "adocDoc" . }}
+{{ end -}}
+`,
+	})
+}
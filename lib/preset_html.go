@@ -0,0 +1,111 @@
+package lib
+
+func init() {
+	RegisterPreset(&Preset{
+		Name:        "html",
+		Description: "a standalone html page",
+		Ext:         ".html",
+		Text: `{{- define "htmlDesc" -}}
+{{ range .Blocks -}}
+{{ if eq .Type.String "code" -}}
+<pre><code>{{ html .Text }}</code></pre>
+{{ else if eq .Type.String "list" -}}
+<{{ if .Ordered }}ol{{ else }}ul{{ end }}>
+{{ range .Items }}  <li>{{ html . }}</li>
+{{ end -}}
+</{{ if .Ordered }}ol{{ else }}ul{{ end }}>
+{{ else -}}
+<p>{{ html .Text }}</p>
+{{ end -}}
+{{ end -}}
+{{ end -}}
+
+{{- define "htmlFn" -}}
+<div class="function" id="{{ anchor . }}">
+<h4><code>{{ html .Signature }}</code></h4>
+{{ template "htmlDesc" .Description -}}
+{{ if gt (len .Params) 0 -}}
+<table class="params">
+<tr><th>name</th><th>type</th><th>description</th></tr>
+{{ range .Params -}}
+<tr><td><code>{{ html .Name }}</code></td><td>{{ html .Type }}</td><td>{{ html (inline .Description) }}</td></tr>
+{{ end -}}
+</table>
+{{ end -}}
+</div>
+{{ end -}}
+
+{{- define "htmlType" -}}
+<div class="type" id="{{ anchor . }}">
+<h3><code>{{ html .Name }}</code></h3>
+{{ template "htmlDesc" .Description -}}
+{{ if gt (len .Fields) 0 -}}
+<h4>Fields</h4>
+<table class="fields">
+<tr><th>name</th><th>type</th><th>description</th></tr>
+{{ range .Fields -}}
+<tr><td>{{ html .Name }}</td><td>{{ html .Type }}</td><td>{{ html (inline .Description) }}</td></tr>
+{{ end -}}
+</table>
+{{ end -}}
+{{ if gt (len .Methods) 0 -}}
+<h4>Methods</h4>
+{{ range .Methods }}{{ template "htmlFn" . }}{{ end -}}
+{{ end -}}
+{{ if gt (len .Operators) 0 -}}
+<h4>Operators</h4>
+<table class="operators">
+<tr><th>operator</th><th>result</th><th>description</th></tr>
+{{ range .Operators -}}
+<tr><td><code>{{ html .Expr }}</code></td><td><code>{{ html .Result }}</code></td><td>{{ html (inline .Description) }}</td></tr>
+{{ end -}}
+</table>
+{{ end -}}
+</div>
+{{ end -}}
+
+{{- define "index" -}}
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Index</title>
+</head>
+<body>
+<h1>Index</h1>
+<ul>
+{{ range . }}  <li><a href="{{ filename . }}#{{ anchor . }}">{{ html .Name }}</a>{{ if ne .Description "" }}: {{ html (inline .Description) }}{{ end }}</li>
+{{ end -}}
+</ul>
+</body>
+</html>
+{{ end -}}
+
+{{- define "htmlDoc" -}}
+<section class="module" id="{{ anchor . }}">
+<h1>{{ html .Name }}</h1>
+{{ template "htmlDesc" .Description -}}
+{{ if gt (len .Functions) 0 -}}
+<h2>Functions</h2>
+{{ range .Functions }}{{ template "htmlFn" . }}{{ end -}}
+{{ end -}}
+{{ if gt (len .Types) 0 -}}
+<h2>Types</h2>
+{{ range .Types }}{{ template "htmlType" . }}{{ end -}}
+{{ end -}}
+</section>
+{{ end -}}
+
+<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{ join ", " .Modules }}</title>
+</head>
+<body>
+{{ range .Modules }}{{ template "htmlDoc" . }}{{ end -}}
+</body>
+</html>
+`,
+	})
+}
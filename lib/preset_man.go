@@ -0,0 +1,112 @@
+package lib
+
+func init() {
+	RegisterPreset(&Preset{
+		Name:        "man",
+		Description: "section 3 man page written in roff, one page per document",
+		Ext:         ".3",
+		Text: `{{- define "manFn" -}}
+.TP
+.B {{ roff (signature .) }}
+{{ if ne .Description "" -}}
+{{ roff .Description }}
+{{ end -}}
+{{ if gt (len .Params) 0 -}}
+.RS
+{{ range .Params -}}
+.TP
+.I {{ roff .Name }}{{ if ne .Type "" }} ({{ roff .Type }}){{ end }}
+{{ if ne .Description "" -}}
+{{ roff .Description }}
+{{ end -}}
+{{ end -}}
+.RE
+{{ end -}}
+{{ end -}}
+
+{{- define "manType" -}}
+.SS {{ roff .Name }}
+{{ if ne .Description "" -}}
+{{ roff .Description }}
+{{ end -}}
+{{ if gt (len .Fields) 0 -}}
+.PP
+.B Fields
+{{ range .Fields -}}
+.TP
+.I {{ roff .Name }}{{ if ne .Type "" }} ({{ roff .Type }}){{ end }}
+{{ if ne .Description "" -}}
+{{ roff .Description }}
+{{ end -}}
+{{ end -}}
+{{ end -}}
+{{ if gt (len .Methods) 0 -}}
+.PP
+.B Methods
+{{ range .Methods }}{{ template "manFn" . }}{{ end -}}
+{{ end -}}
+{{ if gt (len .Operators) 0 -}}
+.PP
+.B Operators
+{{ range .Operators -}}
+.TP
+.B {{ roff .Expr }}{{ if ne .Result "" }} = {{ roff .Result }}{{ end }}
+{{ if ne .Description "" -}}
+{{ roff .Description }}
+{{ end -}}
+{{ end -}}
+{{ end -}}
+{{ end -}}
+
+{{- define "manDoc" -}}
+.TH {{ roff (upper .Name) }} 3 "" "" "Starlark Modules"
+.SH NAME
+{{ roff .Name }}{{ if ne .Description "" }} \- {{ roff (index .Description.Blocks 0).Text }}{{ end }}
+{{ if or (gt (len .Functions) 0) (gt (len .Types) 0) -}}
+.SH SYNOPSIS
+.nf
+{{ range .Functions -}}
+.B {{ roff (signature .) }}
+{{ end -}}
+{{ range .Types }}{{ range .Methods -}}
+.B {{ roff (signature .) }}
+{{ end }}{{ end -}}
+.fi
+{{ end -}}
+.SH DESCRIPTION
+{{ if ne .Description "" -}}
+{{ roff .Description }}
+{{ else -}}
+The {{ roff .Name }} module.
+{{ end -}}
+{{ if gt (len .Functions) 0 -}}
+.SS Functions
+{{ range .Functions }}{{ template "manFn" . }}{{ end -}}
+{{ end -}}
+{{ if gt (len .Types) 0 -}}
+.SH TYPES
+{{ range .Types }}{{ template "manType" . }}{{ end -}}
+{{ end -}}
+{{ with .Examples -}}
+.SH EXAMPLES
+{{ range . -}}
+.SS {{ roff .Name }}
+{{ if ne .Description "" -}}
+{{ roff .Description }}
+{{ end -}}
+{{ if ne .Code "" -}}
+.PP
+.RS 4
+.nf
+{{ roff .Code }}
+.fi
+.RE
+{{ end -}}
+{{ end -}}
+{{ end -}}
+{{ end -}}
+
+{{- range .Modules }}{{ template "manDoc" . }}{{ end -}}
+`,
+	})
+}
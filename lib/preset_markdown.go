@@ -0,0 +1,128 @@
+package lib
+
+// markdownBlocks are the named blocks shared by markdown presets
+const markdownBlocks = `{{- define "mdFn" }}
+<a id="{{ anchor . }}"></a>
+#### {{ code .Signature }}
+{{- if ne .Description "" }}
+{{ .Description }}
+{{- end -}}
+{{- if gt (len .Params) 0 }}
+
+**parameters:**
+
+| name | type | description |
+|------|------|-------------|
+{{ range .Params -}}
+| {{ mdEscape (code .Name) }} | {{ mdEscape (link .) }}{{ if .Default }} = {{ mdEscape (code .Default) }}{{ end }} | {{ mdEscape .Description }} |
+{{ end -}}
+{{- end -}}
+{{- end -}}
+
+{{- define "mdType" -}}
+<a id="{{ anchor . }}"></a>
+### {{ code .Name }}
+{{ if ne .Description "" }}{{ .Description }}{{ end -}}
+{{ if gt (len .Fields) 0 }}
+
+**Fields**
+
+| name | type | description |
+|------|------|-------------|
+{{ range .Fields -}}
+| {{ mdEscape .Name }} | {{ mdEscape (link .) }} | {{ mdEscape .Description }} |
+{{ end -}}
+{{ end -}}
+{{ if gt (len .Methods) 0 }}
+**Methods**
+{{- range .Methods -}}
+{{ template "mdFn" . }}
+{{ end -}}
+{{ end -}}
+{{- if gt (len .Operators) 0 }}
+
+**Operators**
+
+| operator | result | description |
+|----------|--------|-------------|
+{{ range .Operators -}}
+| {{ mdEscape (code .Expr) }} | {{ mdEscape (code .Result) }} | {{ mdEscape .Description }} |
+{{ end }}
+{{ end }}
+{{- end -}}
+
+{{- define "mdDiagram" -}}
+` + "```mermaid" + `
+{{ mermaid . }}` + "```" + `
+{{ end -}}
+
+{{- define "index" -}}
+# Index
+
+{{ range . -}}
+* [{{ .Name }}]({{ filename . }}#{{ anchor . }}){{ if ne .Description "" }}: {{ inline .Description }}{{ end }}
+{{ end -}}
+{{- end -}}
+
+{{- define "mdDoc" -}}
+<a id="{{ anchor . }}"></a>
+# {{ .Name }}
+{{ if ne .Description "" }}{{ .Description }}{{ end }}
+{{- if gt (len .Functions) 0 }}
+
+## Functions
+{{ range .Functions -}}
+{{ template "mdFn" . }}
+{{ end -}}
+{{- end }}
+{{ if gt (len .Types) 0 }}
+## Types
+
+{{ if diagrams }}{{ template "mdDiagram" . }}
+{{ end -}}
+{{ range .Types -}}
+{{ template "mdType" . }}
+{{- end -}}
+{{- end -}}
+{{ end -}}
+`
+
+func init() {
+	RegisterPreset(&Preset{
+		Name:        "markdown",
+		Description: "markdown, one section per document",
+		Ext:         ".md",
+		Text: markdownBlocks + `
+{{- range .Modules -}}
+{{ template "mdDoc" . }}
+{{- end -}}`,
+	})
+
+	RegisterPreset(&Preset{
+		Name:        "markdown-single-page",
+		Description: "markdown with a table of contents linking every document, function & type",
+		Ext:         ".md",
+		Text: markdownBlocks + `
+{{- define "mdContents" -}}
+## Contents
+
+{{ range .Modules -}}
+* [{{ .Name }}](#{{ anchor . }})
+{{ range .Functions -}}
+{{ "  " }}* [{{ code .Name }}](#{{ anchor . }})
+{{ end -}}
+{{ range .Types -}}
+{{ "  " }}* [{{ code .Name }}](#{{ anchor . }})
+{{ range .Methods -}}
+{{ "    " }}* [{{ code .Name }}](#{{ anchor . }})
+{{ end -}}
+{{ end -}}
+{{ end }}
+{{ end -}}
+
+{{- template "mdContents" . -}}
+{{- range .Modules -}}
+{{ template "mdDoc" . }}
+{{- end -}}`,
+	})
+}
@@ -0,0 +1,103 @@
+package lib
+
+func init() {
+	RegisterPreset(&Preset{
+		Name:        "rst",
+		Description: "reStructuredText using Sphinx python domain directives",
+		Ext:         ".rst",
+		Text: `{{- define "index" -}}
+Index
+=====
+
+.. toctree::
+   :maxdepth: 1
+
+{{ range . }}   {{ trimExt (filename .) }}
+{{ end -}}
+{{ end -}}
+
+{{- define "rstFnBody" -}}
+{{ with .Description }}
+{{ indent 3 (rst .) }}
+{{ end -}}
+{{ if or (gt (len .Params) 0) (ne .ReturnType "") }}
+{{ range .Params -}}
+{{ "   " }}:param {{ .Name }}:{{ with .Description }} {{ rst (inline .) }}{{ end }}
+{{ if ne .Type "" }}   :type {{ .Name }}: {{ qualify . }}
+{{ end -}}
+{{ end -}}
+{{ if ne .ReturnType "" }}   :rtype: {{ qualify . }}
+{{ end -}}
+{{ end -}}
+{{ range .Examples }}
+   **Example:** {{ .Name }}
+{{ with .Description }}
+{{ indent 3 (rst .) }}
+{{ end -}}
+{{ if ne .Code "" }}
+   .. code-block:: python
+
+{{ indent 6 .Code }}
+{{ end -}}
+{{ end -}}
+{{ end -}}
+
+{{- define "rstFn" -}}
+.. function:: {{ .Name }}({{ join ", " (args .) }})
+{{ template "rstFnBody" . }}
+{{ end -}}
+
+{{- define "rstType" -}}
+.. class:: {{ .Name }}
+{{ with .Description }}
+{{ indent 3 (rst .) }}
+{{ end -}}
+{{ if gt (len .Operators) 0 }}
+   **Operators**
+
+{{ range .Operators -}}
+{{ "   " }}- ` + "``{{ .Expr }}``{{ if ne .Result \"\" }} = {{ qualify .Result }}{{ end }}" + `{{ with .Description }}: {{ rst (inline .) }}{{ end }}
+{{ end -}}
+{{ end }}
+{{ $type := .Name -}}
+{{ range .Fields -}}
+.. attribute:: {{ $type }}.{{ .Name }}
+{{ if ne .Type "" }}   :type: {{ qualify . }}
+{{ end -}}
+{{ with .Description }}
+{{ indent 3 (rst .) }}
+{{ end }}
+{{ end -}}
+{{ range .Methods -}}
+.. method:: {{ $type }}.{{ .Name }}({{ join ", " (args .) }})
+{{ template "rstFnBody" . }}
+{{ end -}}
+{{ end -}}
+
+{{- define "rstDoc" -}}
+{{ .Name }}
+{{ repeat (len .Name) "=" }}
+
+.. module:: {{ .Name }}
+{{ with .Description }}
+{{ rst . }}
+{{ end -}}
+{{ if gt (len .Functions) 0 }}
+Functions
+---------
+
+{{ range .Functions }}{{ template "rstFn" . }}{{ end -}}
+{{ end -}}
+{{ if gt (len .Types) 0 }}
+Types
+-----
+
+{{ range .Types }}{{ template "rstType" . }}{{ end -}}
+{{ end -}}
+{{ end -}}
+
+{{- range .Modules }}{{ template "rstDoc" . }}
+{{ end -}}
+`,
+	})
+}
@@ -0,0 +1,137 @@
+package lib
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	docs, err := Parse(strings.NewReader(resolveText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _ := docs.Resolve()
+
+	for _, name := range []string{"markdown", "markdown-single-page", "html", "man", "rst", "asciidoc"} {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := LoadTemplate(name, nil, TemplateFuncs(table))
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf := &bytes.Buffer{}
+			if err := tmpl.Execute(buf, docs); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), "within") {
+				t.Errorf("expected output to document function \"within\":\n%s", buf.String())
+			}
+		})
+	}
+
+	if _, err := LoadTemplate("pdf", nil, TemplateFuncs(table)); err == nil || !strings.Contains(err.Error(), "available formats: asciidoc, html, man") {
+		t.Errorf("expected unknown format error to list formats, got: %v", err)
+	}
+}
+
+func TestManPreset(t *testing.T) {
+	docs, err := Parse(strings.NewReader(resolveText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _ := docs.Resolve()
+	tmpl, err := LoadTemplate("man", nil, TemplateFuncs(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, docs[:1]); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	for _, expect := range []string{
+		".TH GEO 3",
+		".SH NAME\ngeo\n",
+		".SH SYNOPSIS\n.nf\n.B geo.point(lat,lng float) point\n.B geo.within(geomA,geomB) bool\n.B point.buffer(x int) polygon\n",
+		".SH DESCRIPTION\n",
+		".SH TYPES\n.SS point\n",
+		".B point \\- point = time.duration\n",
+	} {
+		if !strings.Contains(got, expect) {
+			t.Errorf("expected man page to contain %q, got:\n%s", expect, got)
+		}
+	}
+}
+
+func TestRstPreset(t *testing.T) {
+	docs, err := Parse(strings.NewReader(resolveText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _ := docs.Resolve()
+	tmpl, err := LoadTemplate("rst", nil, TemplateFuncs(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, docs); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	for _, expect := range []string{
+		".. module:: geo\n",
+		".. function:: point(lat, lng)\n\n   :param lat:\n   :type lat: float\n",
+		"   :type geomA: [geo.point,geo.line,geo.polygon]\n",
+		".. class:: point\n",
+		".. attribute:: point.x\n   :type: float\n",
+		".. method:: point.travel(d)\n\n   :rtype: geo.point\n",
+		"   - ``point - point`` = time.duration\n",
+		".. attribute:: duration.parent\n   :type: clock\n",
+	} {
+		if !strings.Contains(got, expect) {
+			t.Errorf("expected rst output to contain %q, got:\n%s", expect, got)
+		}
+	}
+}
+
+func TestLoadTemplateOverrides(t *testing.T) {
+	docs, err := Parse(strings.NewReader(resolveText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _ := docs.Resolve()
+
+	dir := writeOutlines(t, map[string]string{
+		"block/mdFn.tmpl":        `{{ define "mdFn" }}FUNCTION {{ .Name }}{{ end }}`,
+		"site/a_partials.tmpl":   `{{ define "heading" }}## {{ .Name }}{{ end }}`,
+		"site/b_index.tmpl":      `{{ range . }}{{ template "heading" . }} {{ len .Functions }}{{ end }}`,
+		"site/ignored.md":        `not a template`,
+		"site/nested/other.tmpl": `nested directories aren't read`,
+	})
+	defer os.RemoveAll(dir)
+
+	execute := func(paths ...string) string {
+		tmpl, err := LoadTemplate("markdown", paths, TemplateFuncs(table))
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := &bytes.Buffer{}
+		if err := tmpl.Execute(buf, docs); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	got := execute(filepath.Join(dir, "block/mdFn.tmpl"))
+	if !strings.Contains(got, "FUNCTION within") || !strings.Contains(got, "# geo") {
+		t.Errorf("expected mdFn block to be overridden within the markdown preset, got:\n%s", got)
+	}
+
+	if got := execute(filepath.Join(dir, "site")); got != "## geo 2## time 0" {
+		t.Errorf("unexpected template directory output: %q", got)
+	}
+}
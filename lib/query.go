@@ -0,0 +1,479 @@
+package lib
+
+import (
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Query selects elements from outline documents. Queries are written as a
+// path of dot-separated steps, each optionally followed by filters in square
+// brackets:
+//
+//	geo.types.point.methods[name=buffer]
+//	*.functions[!examples]
+//	**[return=duration]
+//
+// The first step matches document names. Each following step is one of:
+//
+//	functions, types, modules, methods, fields, params, operators, examples
+//	        select a section of each element. modules are the submodules of
+//	        a document
+//	a name or glob pattern, eg: "point" or "get*"
+//	        match elements by name. Directly after a section the section's
+//	        elements are matched, otherwise the children of each element are
+//	*       match all elements, as with a name
+//	**      select every element nested within each element, at any depth
+//
+// Filters test an attribute of each element:
+//
+//	[attr]          attribute is set. for sections, the section isn't empty
+//	[!attr]         attribute isn't set
+//	[attr=value]    attribute equals value
+//	[attr!=value]   attribute doesn't equal value
+//	[attr^=value]   attribute starts with value
+//	[attr$=value]   attribute ends with value
+//	[attr*=value]   attribute contains value
+//
+// Attributes are name, description, signature, return, receiver, type, path,
+// code, symbol, left, right, result & optional, along with section names,
+// which compare as the number of elements in the section. Values may be
+// quoted with double or single quotes
+type Query struct {
+	text  string
+	steps []*queryStep
+}
+
+type queryStep struct {
+	// section names a section keyword, eg: "functions". empty for name steps
+	section string
+	// pattern is a name glob for name steps, "**" for descendant steps
+	pattern string
+	filters []*queryFilter
+}
+
+type queryFilter struct {
+	attr   string
+	op     string // one of "", "=", "!=", "^=", "$=", "*="
+	value  string
+	negate bool
+}
+
+// querySections is the set of section keywords queries can select
+var querySections = map[string]bool{
+	"functions": true,
+	"types":     true,
+	"methods":   true,
+	"fields":    true,
+	"params":    true,
+	"operators": true,
+	"examples":  true,
+	"modules":   true,
+}
+
+// ParseQuery parses query text
+func ParseQuery(text string) (*Query, error) {
+	q := &Query{text: text}
+	s := text
+	for {
+		step := &queryStep{}
+		i := strings.IndexAny(s, ".[")
+		if i == -1 {
+			i = len(s)
+		}
+		name := strings.TrimSpace(s[:i])
+		if name == "" {
+			return nil, fmt.Errorf("query %q: expected a name at offset %d", text, len(text)-len(s))
+		}
+		if querySections[name] && len(q.steps) > 0 {
+			step.section = name
+		} else {
+			if _, err := path.Match(name, ""); err != nil {
+				return nil, fmt.Errorf("query %q: invalid pattern %q", text, name)
+			}
+			step.pattern = name
+		}
+		s = s[i:]
+
+		for strings.HasPrefix(s, "[") {
+			f, rest, err := parseQueryFilter(s)
+			if err != nil {
+				return nil, fmt.Errorf("query %q: %s", text, err)
+			}
+			step.filters = append(step.filters, f)
+			s = rest
+		}
+		q.steps = append(q.steps, step)
+
+		if s == "" {
+			return q, nil
+		}
+		if s[0] != '.' {
+			return nil, fmt.Errorf("query %q: unexpected %q at offset %d", text, s[0], len(text)-len(s))
+		}
+		s = s[1:]
+	}
+}
+
+// parseQueryFilter reads a bracketed filter from the start of s
+func parseQueryFilter(s string) (f *queryFilter, rest string, err error) {
+	end := -1
+	var quote byte
+	for i := 1; i < len(s); i++ {
+		switch {
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == ']':
+			end = i
+		}
+		if end != -1 {
+			break
+		}
+	}
+	if end == -1 {
+		return nil, "", fmt.Errorf("unterminated filter %q", s)
+	}
+
+	body, rest := strings.TrimSpace(s[1:end]), s[end+1:]
+	f = &queryFilter{}
+	if strings.HasPrefix(body, "!") && !strings.HasPrefix(body, "!=") {
+		f.negate = true
+		body = strings.TrimSpace(body[1:])
+	}
+
+	if i, op := filterOperator(body); op != "" {
+		f.attr, f.op = strings.TrimSpace(body[:i]), op
+		f.value = strings.TrimSpace(body[i+len(op):])
+		if unquoted, err := strconv.Unquote(f.value); err == nil {
+			f.value = unquoted
+		} else if len(f.value) >= 2 && f.value[0] == '\'' && f.value[len(f.value)-1] == '\'' {
+			f.value = f.value[1 : len(f.value)-1]
+		}
+	}
+	if f.op == "" {
+		f.attr = body
+	}
+	if f.attr == "" {
+		return nil, "", fmt.Errorf("filter %q is missing an attribute", s[:end+1])
+	}
+	if f.negate && f.op != "" {
+		return nil, "", fmt.Errorf("filter %q: use != to negate a comparison", s[:end+1])
+	}
+	return f, rest, nil
+}
+
+// filterOperator finds the first comparison operator in a filter body that
+// isn't within a quoted value, returning its offset
+func filterOperator(body string) (int, string) {
+	var quote byte
+	for i := 0; i < len(body); i++ {
+		switch {
+		case quote != 0:
+			if body[i] == quote {
+				quote = 0
+			}
+		case body[i] == '"' || body[i] == '\'':
+			quote = body[i]
+		default:
+			for _, op := range []string{"!=", "^=", "$=", "*=", "="} {
+				if strings.HasPrefix(body[i:], op) {
+					return i, op
+				}
+			}
+		}
+	}
+	return -1, ""
+}
+
+// String returns the query as written
+func (q *Query) String() string {
+	return q.text
+}
+
+// Match is an element selected by a query
+type Match struct {
+	Node Node
+	// Path lists the ancestors of the element, starting with its document
+	Path Path
+}
+
+// Kind names the type of element matched: module, function, method, param,
+// type, field, operator or example
+func (m *Match) Kind() string {
+	return nodeKind(m.Node, m.Path)
+}
+
+// Name returns the dot-separated names of the element & its ancestors, eg:
+// "geo.point.buffer"
+func (m *Match) Name() string {
+	names := make([]string, 0, len(m.Path)+1)
+	for _, n := range append(m.Path[:len(m.Path):len(m.Path)], m.Node) {
+		switch x := n.(type) {
+		case Docs:
+			continue
+		case *Doc:
+			// document names are already qualified by the modules they're
+			// nested in
+			names = append(names[:0], x.Name)
+			continue
+		}
+		names = append(names, elementName(n))
+	}
+	return strings.Join(names, ".")
+}
+
+// stepName is the name a query step matches an element by. Submodules are
+// matched by the name they're written with within their parent
+func (m *Match) stepName() string {
+	if doc, ok := m.Node.(*Doc); ok && m.Path.Doc() != nil {
+		return doc.Basename()
+	}
+	return elementName(m.Node)
+}
+
+// nodeKind names the type of an element
+func nodeKind(n Node, path Path) string {
+	switch n.(type) {
+	case *Doc:
+		return "module"
+	case *Function:
+		if _, ok := path.Parent().(*Type); ok {
+			return "method"
+		}
+		return "function"
+	case *Param:
+		return "param"
+	case *Type:
+		return "type"
+	case *Field:
+		return "field"
+	case *Operator:
+		return "operator"
+	case *Example:
+		return "example"
+	}
+	return ""
+}
+
+// Select returns the elements of docs that match the query, in document
+// order. Elements are only returned once, even if they match more than one
+// way
+func (q *Query) Select(docs Docs) (matches []*Match) {
+	var cur []*Match
+	for _, doc := range docs {
+		cur = append(cur, &Match{Node: doc})
+	}
+
+	// collection is true while cur holds the elements of a section, all
+	// descendants of an element, or the documents themselves, which name steps
+	// match against directly
+	collection := true
+	for i, step := range q.steps {
+		var next []*Match
+		switch {
+		case step.section != "":
+			for _, m := range cur {
+				next = append(next, section(m, step.section)...)
+			}
+			collection = true
+		case step.pattern == "**":
+			for _, m := range cur {
+				if i == 0 {
+					next = append(next, m)
+				}
+				next = append(next, descendants(m)...)
+			}
+			collection = true
+		default:
+			candidates := cur
+			if !collection {
+				candidates = nil
+				for _, m := range cur {
+					candidates = append(candidates, children(m)...)
+				}
+			}
+			for _, m := range candidates {
+				if ok, _ := path.Match(step.pattern, m.stepName()); ok {
+					next = append(next, m)
+				}
+			}
+			collection = false
+		}
+
+		cur = nil
+		for _, m := range next {
+			if step.matches(m) {
+				cur = append(cur, m)
+			}
+		}
+	}
+
+	seen := map[Node]bool{}
+	for _, m := range cur {
+		if !seen[m.Node] {
+			seen[m.Node] = true
+			matches = append(matches, m)
+		}
+	}
+	return matches
+}
+
+// children lists the direct children of a matched element
+func children(m *Match) (list []*Match) {
+	parents := append(m.Path[:len(m.Path):len(m.Path)], m.Node)
+	for _, child := range Children(m.Node) {
+		list = append(list, &Match{Node: child, Path: parents})
+	}
+	return list
+}
+
+// descendants lists every element nested within a matched element
+func descendants(m *Match) (list []*Match) {
+	Inspect(m.Node, func(n Node, parents Path) bool {
+		if n != nil && n != m.Node {
+			full := append(m.Path[:len(m.Path):len(m.Path)], parents...)
+			list = append(list, &Match{Node: n, Path: full})
+		}
+		return true
+	})
+	return list
+}
+
+// section lists the elements of a named section of a matched element
+func section(m *Match, name string) (list []*Match) {
+	for _, child := range children(m) {
+		if sectionTok(m.Node, child.Node).String() == name {
+			list = append(list, child)
+		}
+	}
+	return list
+}
+
+// matches reports whether an element passes every filter of a step
+func (s *queryStep) matches(m *Match) bool {
+	for _, f := range s.filters {
+		if !f.matches(m) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *queryFilter) matches(m *Match) bool {
+	value, ok := attribute(m, f.attr)
+	switch f.op {
+	case "":
+		set := ok && value != "" && value != "0" && value != "false"
+		return set != f.negate
+	case "=":
+		return ok && value == f.value
+	case "!=":
+		return !ok || value != f.value
+	case "^=":
+		return ok && strings.HasPrefix(value, f.value)
+	case "$=":
+		return ok && strings.HasSuffix(value, f.value)
+	case "*=":
+		return ok && strings.Contains(value, f.value)
+	}
+	return false
+}
+
+// attribute reads a named attribute of a matched element as a string. ok is
+// false when the element doesn't have the attribute
+func attribute(m *Match, attr string) (value string, ok bool) {
+	if attr == "name" {
+		return m.stepName(), true
+	}
+	if attr == "kind" {
+		return m.Kind(), true
+	}
+	if querySections[attr] {
+		n := 0
+		for _, child := range Children(m.Node) {
+			if sectionTok(m.Node, child).String() == attr {
+				n++
+			}
+		}
+		switch m.Node.(type) {
+		case *Doc:
+			ok = attr == "functions" || attr == "types" || attr == "modules"
+		case *Function:
+			ok = attr == "params" || attr == "examples"
+		case *Type:
+			ok = attr == "methods" || attr == "fields" || attr == "operators"
+		}
+		return strconv.Itoa(n), ok
+	}
+
+	switch x := m.Node.(type) {
+	case *Doc:
+		switch attr {
+		case "description":
+			return x.Description.String(), true
+		case "path":
+			return x.Path, true
+		}
+	case *Function:
+		switch attr {
+		case "description":
+			return x.Description.String(), true
+		case "signature":
+			return x.Signature, true
+		case "return":
+			return x.ReturnType(), true
+		case "receiver":
+			return x.Receiver, true
+		}
+	case *Param:
+		switch attr {
+		case "description":
+			return x.Description.String(), true
+		case "type":
+			return x.Type, true
+		case "optional":
+			return strconv.FormatBool(x.Optional), true
+		case "default":
+			return x.Default, true
+		case "variadic":
+			return strconv.FormatBool(x.Variadic || x.Kwargs), true
+		}
+	case *Type:
+		if attr == "description" {
+			return x.Description.String(), true
+		}
+	case *Field:
+		switch attr {
+		case "description":
+			return x.Description.String(), true
+		case "type":
+			return x.Type, true
+		}
+	case *Operator:
+		switch attr {
+		case "description":
+			return x.Description.String(), true
+		case "symbol":
+			return x.Symbol, true
+		case "left":
+			return x.Left, true
+		case "right":
+			return x.Right, true
+		case "result":
+			return x.Result, true
+		}
+	case *Example:
+		switch attr {
+		case "description":
+			return x.Description.String(), true
+		case "code":
+			return x.Code, true
+		}
+	}
+	return "", false
+}
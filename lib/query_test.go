@@ -0,0 +1,197 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const queryText = `
+outline: geo
+  functions:
+    point(lat,lng float) point
+      build a point
+      params:
+        lat float
+        lng float
+      examples:
+        origin
+          code:
+            geo.point(0, 0)
+    within(geomA,geomB) bool
+  types:
+    point
+      methods:
+        buffer(r float) polygon
+        since(t time) duration
+      fields:
+        x float
+        label string
+      operators:
+        point - point = duration
+
+outline: time
+  functions:
+    now() time
+    parse_duration(s string) duration
+      params:
+        s string`
+
+func TestQuerySelect(t *testing.T) {
+	docs, err := Parse(strings.NewReader(queryText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		query  string
+		expect []string
+	}{
+		{"geo", []string{"module geo"}},
+		{"*", []string{"module geo", "module time"}},
+		{"geo.types.point.methods[name=buffer]", []string{"method geo.point.buffer"}},
+		{"geo.point", []string{"function geo.point", "type geo.point"}},
+		{"geo.point.buffer", []string{"method geo.point.buffer"}},
+		{"*.functions[!examples]", []string{"function geo.within", "function time.now", "function time.parse_duration"}},
+		{"*.functions[examples]", []string{"function geo.point"}},
+		{"*.functions[!description]", []string{"function geo.within", "function time.now", "function time.parse_duration"}},
+		{"**[return=duration]", []string{"method geo.point.since", "function time.parse_duration"}},
+		{"**[result=duration]", []string{"operator geo.point.point - point = duration"}},
+		{"geo.types.*.fields[type!=float]", []string{"field geo.point.label"}},
+		{"*.functions.*[name^=parse]", []string{"function time.parse_duration"}},
+		{"time.*.params", []string{"param time.parse_duration.s"}},
+		{"**.s", []string{"param time.parse_duration.s"}},
+		{"geo.**[kind=example]", []string{"example geo.point.origin"}},
+		{`geo.**[code*="0, 0"]`, []string{"example geo.point.origin"}},
+		{"*.functions[params=2]", []string{"function geo.point"}},
+		{"nope.functions", nil},
+	}
+
+	for _, c := range cases {
+		q, err := ParseQuery(c.query)
+		if err != nil {
+			t.Errorf("parsing %q: %s", c.query, err)
+			continue
+		}
+		var got []string
+		for _, m := range q.Select(docs) {
+			got = append(got, m.Kind()+" "+m.Name())
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("%s: matches mismatch (-want +got):\n%s", c.query, diff)
+		}
+	}
+}
+
+func TestParseQueryFilter(t *testing.T) {
+	cases := []struct {
+		filter          string
+		attr, op, value string
+	}{
+		{`[name=point]`, "name", "=", "point"},
+		{`[code*="a != b"]`, "code", "*=", "a != b"},
+		{`[name!='x=1']`, "name", "!=", "x=1"},
+		{`[description^="a ] b"]`, "description", "^=", "a ] b"},
+	}
+	for _, c := range cases {
+		f, _, err := parseQueryFilter(c.filter)
+		if err != nil {
+			t.Errorf("%s: %s", c.filter, err)
+			continue
+		}
+		if f.attr != c.attr || f.op != c.op || f.value != c.value {
+			t.Errorf("%s: expected %q %q %q, got: %q %q %q", c.filter, c.attr, c.op, c.value, f.attr, f.op, f.value)
+		}
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	cases := []struct {
+		query, err string
+	}{
+		{"", `query "": expected a name at offset 0`},
+		{"geo..functions", `query "geo..functions": expected a name at offset 4`},
+		{"geo.functions[name=a", `query "geo.functions[name=a": unterminated filter "[name=a"`},
+		{"geo.functions[]", `query "geo.functions[]": filter "[]" is missing an attribute`},
+		{"geo.functions[!name=a]", `query "geo.functions[!name=a]": filter "[!name=a]": use != to negate a comparison`},
+		{"geo.functions[name]x", `query "geo.functions[name]x": unexpected 'x' at offset 19`},
+		{"geo.[a", `query "geo.[a": expected a name at offset 4`},
+	}
+	for _, c := range cases {
+		_, err := ParseQuery(c.query)
+		if err == nil || err.Error() != c.err {
+			t.Errorf("%q: expected error %q, got: %v", c.query, c.err, err)
+		}
+	}
+}
+
+func TestMarshalNode(t *testing.T) {
+	docs, err := Parse(strings.NewReader(queryText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := MarshalNode(docs[0].Functions[0], "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := `point(lat,lng float) point
+  build a point
+  params:
+    lat float
+    lng float
+  examples:
+    origin
+      code:
+        geo.point(0, 0)
+`
+	if diff := cmp.Diff(expect, string(data)); diff != "" {
+		t.Errorf("marshaled function mismatch (-want +got):\n%s", diff)
+	}
+
+	// documents survive a round trip
+	data, err = MarshalNode(docs, "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(strings.NewReader(string(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(docs, got, ignoreUnexported); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestQuerySubmodules(t *testing.T) {
+	docs, err := Parse(strings.NewReader(modulesText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		query  string
+		expect []string
+	}{
+		{"http.modules", []string{"module http.client", "module http.server"}},
+		{"http.server.mux.functions", []string{"function http.server.mux.handle"}},
+		{"http.client.types.conn", []string{"type http.client.conn"}},
+		{"*[modules]", []string{"module http"}},
+		{"**[name=mux]", []string{"module http.server.mux"}},
+	}
+	for _, c := range cases {
+		q, err := ParseQuery(c.query)
+		if err != nil {
+			t.Errorf("parsing %q: %s", c.query, err)
+			continue
+		}
+		var got []string
+		for _, m := range q.Select(docs) {
+			got = append(got, m.Kind()+" "+m.Name())
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("%s: matches mismatch (-want +got):\n%s", c.query, diff)
+		}
+	}
+}
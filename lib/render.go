@@ -0,0 +1,125 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+)
+
+// WriteDir renders each document to its own file in dir. t must be a template
+// loaded with TemplateFuncs(table), and is executed with a Docs list holding
+// a single document. Filenames are produced by executing the pattern template
+// against each document, eg: "{{ .Name }}.md".
+//
+// If index is not empty and t defines an "index" block, the index block is
+// executed against all documents & written to a file named index. Within
+// templates the filename function returns the file a document is written to,
+// and links to types declared in other documents point to the other file.
+//
+// Files are only written when their content changes, WriteDir returns the
+// paths of files that were written. t itself isn't modified or executed
+func WriteDir(t *template.Template, table *SymbolTable, docs Docs, dir, pattern, index string) (written []string, err error) {
+	names, err := Filenames(table, docs, pattern)
+	if err != nil {
+		return nil, err
+	}
+	for _, doc := range docs {
+		if name := names[doc]; name == index {
+			return nil, fmt.Errorf("document %q would overwrite index file %s", doc.Name, index)
+		}
+	}
+
+	// filename is set on a clone, leaving the caller's template unchanged
+	if t, err = t.Clone(); err != nil {
+		return nil, err
+	}
+	page := func(doc *Doc) string { return names[doc] }
+	table.SetPages(page)
+	defer table.SetPages(nil)
+	t.Funcs(template.FuncMap{"filename": page})
+
+	write := func(name string, exec func(buf *bytes.Buffer) error) error {
+		buf := &bytes.Buffer{}
+		if err := exec(buf); err != nil {
+			return err
+		}
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		changed, err := writeIfChanged(path, buf.Bytes())
+		if err != nil {
+			return err
+		}
+		if changed {
+			written = append(written, path)
+		}
+		return nil
+	}
+
+	for _, doc := range docs {
+		err := write(names[doc], func(buf *bytes.Buffer) error {
+			return t.Execute(buf, Docs{doc})
+		})
+		if err != nil {
+			return written, err
+		}
+	}
+
+	if index != "" && t.Lookup("index") != nil {
+		err := write(index, func(buf *bytes.Buffer) error {
+			return t.ExecuteTemplate(buf, "index", docs)
+		})
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+// Filenames names the file each document is written to by executing the
+// pattern template against each document, eg: "{{ .Name }}.md". Names are
+// slash-separated paths, it's an error for two documents to share a name.
+// Submodules share the file of the top-level document they're nested in
+func Filenames(table *SymbolTable, docs Docs, pattern string) (map[*Doc]string, error) {
+	nameTmpl, err := template.New("filename").Funcs(TemplateFuncs(table)).Parse(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("parsing filename pattern: %s", err)
+	}
+
+	names := map[*Doc]string{}
+	owners := map[string]*Doc{}
+	for _, doc := range docs {
+		buf := &bytes.Buffer{}
+		if err := nameTmpl.Execute(buf, doc); err != nil {
+			return nil, fmt.Errorf("naming %q: %s", doc.Name, err)
+		}
+		name := filepath.ToSlash(filepath.Clean(buf.String()))
+		if name == "." || name == "" {
+			return nil, fmt.Errorf("filename pattern produced an empty name for %q", doc.Name)
+		}
+		if other, ok := owners[name]; ok {
+			return nil, fmt.Errorf("documents %q and %q are both written to %s", other.Name, doc.Name, name)
+		}
+		names[doc] = name
+		owners[name] = doc
+		// submodules are written in the file of the document they're nested in
+		for _, mod := range doc.Submodules.Modules() {
+			names[mod] = name
+		}
+	}
+	return names, nil
+}
+
+// writeIfChanged writes data to a file, creating parent directories as needed.
+// The file is left untouched if it already holds data
+func writeIfChanged(path string, data []byte) (changed bool, err error) {
+	if existing, err := ioutil.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return false, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return false, err
+	}
+	return true, ioutil.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,122 @@
+package lib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteDir(t *testing.T) {
+	docs, err := Parse(strings.NewReader(resolveText + "\n        elapsed geo.point"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _ := docs.Resolve()
+	tmpl, err := LoadTemplate("markdown", nil, TemplateFuncs(table))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "outline_render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	written, err := WriteDir(tmpl, table, docs, dir, "{{ .Name }}/README.md", "index.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{
+		filepath.Join(dir, "geo/README.md"),
+		filepath.Join(dir, "time/README.md"),
+		filepath.Join(dir, "index.md"),
+	}
+	if diff := cmp.Diff(expect, written); diff != "" {
+		t.Errorf("written files mismatch (-want +got):\n%s", diff)
+	}
+
+	index, err := ioutil.ReadFile(filepath.Join(dir, "index.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(index), "* [geo](geo/README.md#module-geo)") {
+		t.Errorf("expected index to link to document files, got:\n%s", index)
+	}
+
+	time, err := ioutil.ReadFile(filepath.Join(dir, "time/README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(time), "[geo.point](../geo/README.md#type-geo-point)") {
+		t.Errorf("expected links to other documents to include the document file, got:\n%s", time)
+	}
+	if strings.Contains(string(time), "# geo") {
+		t.Errorf("expected time page to only contain the time document, got:\n%s", time)
+	}
+
+	// unchanged files aren't rewritten
+	if written, err = WriteDir(tmpl, table, docs, dir, "{{ .Name }}/README.md", "index.md"); err != nil {
+		t.Fatal(err)
+	}
+	if len(written) != 0 {
+		t.Errorf("expected no files to be rewritten, got: %v", written)
+	}
+
+	if _, err := WriteDir(tmpl, table, docs, dir, "same.md", ""); err == nil {
+		t.Error("expected documents written to the same file to error")
+	}
+
+	// the filename func of the caller's template is left as it was
+	check, err := tmpl.New("check").Parse("{{ filename . }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &strings.Builder{}
+	if err := check.Execute(buf, docs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected WriteDir not to change the template's filename func, got: %q", buf)
+	}
+}
+
+func TestHrefPages(t *testing.T) {
+	text := `outline: http
+  types:
+    request
+  modules:
+    client
+      functions:
+        get(url string) http.request
+
+outline: geo
+  types:
+    point
+      fields:
+        owner http.request`
+	docs, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _ := docs.Resolve()
+	names, err := Filenames(table, docs, "{{ .Name }}/README.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.SetPages(func(d *Doc) string { return names[d] })
+
+	http, geo := docs[0], docs[1]
+	// submodules share the page of their parent
+	if got := table.Link(http.Submodules[0].Functions[0]); got != "[http.request](#type-http-request)" {
+		t.Errorf("unexpected link within a page: %s", got)
+	}
+	// links between pages are relative to the linking page
+	if got := table.Link(geo.Types[0].Fields[0]); got != "[http.request](../http/README.md#type-http-request)" {
+		t.Errorf("unexpected link between pages: %s", got)
+	}
+}
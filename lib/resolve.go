@@ -0,0 +1,368 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// builtinTypes are starlark types that resolve without being declared in an
+// outline document
+var builtinTypes = map[string]bool{
+	"any":      true,
+	"bool":     true,
+	"boolean":  true,
+	"booleans": true,
+	"builtin":  true,
+	"bytes":    true,
+	"callable": true,
+	"dict":     true,
+	"float":    true,
+	"function": true,
+	"int":      true,
+	"iterable": true,
+	"list":     true,
+	"none":     true,
+	"None":     true,
+	"NoneType": true,
+	"object":   true,
+	"sequence": true,
+	"set":      true,
+	"str":      true,
+	"string":   true,
+	"tuple":    true,
+}
+
+// SymbolKind enumerates the kinds of named elements in an outline
+type SymbolKind int
+
+const (
+	// ModuleSymbol is an outline document
+	ModuleSymbol SymbolKind = iota
+	// TypeSymbol is a type declared in a document
+	TypeSymbol
+	// FunctionSymbol is a module-level function
+	FunctionSymbol
+	// MethodSymbol is a function attached to a type
+	MethodSymbol
+)
+
+// String implements the stringer interface for SymbolKind
+func (k SymbolKind) String() string {
+	switch k {
+	case ModuleSymbol:
+		return "module"
+	case TypeSymbol:
+		return "type"
+	case FunctionSymbol:
+		return "function"
+	case MethodSymbol:
+		return "method"
+	default:
+		return "unknown"
+	}
+}
+
+// Symbol is a named element that references can resolve to
+type Symbol struct {
+	// Name is the fully qualified name of the symbol, eg: "time.duration.add"
+	Name     string
+	Kind     SymbolKind
+	Doc      *Doc
+	Type     *Type     // set for types & methods
+	Function *Function // set for functions & methods
+}
+
+// Anchor returns a URL fragment identifier for the symbol. Anchors are
+// prefixed with the symbol kind, because starlark modules commonly declare a
+// type and a constructor function that share a name
+func (s *Symbol) Anchor() string {
+	return Anchor(s.Kind.String() + " " + s.Name)
+}
+
+// Anchor converts a name into a URL fragment identifier by lower-casing it &
+// replacing runs of anything that isn't a letter or number with a dash
+func Anchor(name string) string {
+	buf := &bytes.Buffer{}
+	dash := false
+	for _, ch := range strings.ToLower(name) {
+		if ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' {
+			if dash && buf.Len() > 0 {
+				buf.WriteRune('-')
+			}
+			dash = false
+			buf.WriteRune(ch)
+			continue
+		}
+		dash = true
+	}
+	return buf.String()
+}
+
+// SymbolTable indexes the symbols declared in a set of documents, and
+// resolves type references against them
+type SymbolTable struct {
+	// modules & types share a namespace that type references resolve against
+	types map[string]*Symbol
+	// functions & methods are indexed separately
+	funcs map[string]*Symbol
+	// elements maps documents, types & functions to their symbols
+	elements map[interface{}]*Symbol
+	// scopes maps documented elements to their enclosing document
+	scopes map[interface{}]*Doc
+	// page returns the file a document is written to, when documents are
+	// written to separate files
+	page func(*Doc) string
+}
+
+// SetPages sets the function that names the file each document is written
+// to. Links to symbols declared in a different document than the one being
+// written are prefixed with the file of the declaring document
+func (t *SymbolTable) SetPages(page func(*Doc) string) {
+	t.page = page
+}
+
+// Href returns the URL of a symbol as seen from a document. When documents
+// are written to separate files, links to symbols on another page are relative
+// to the page of from, or to the output directory when from is nil
+func (t *SymbolTable) Href(s *Symbol, from *Doc) string {
+	if t.page == nil {
+		return "#" + s.Anchor()
+	}
+	target := t.page(s.Doc)
+	if from == nil {
+		return target + "#" + s.Anchor()
+	}
+	page := t.page(from)
+	if page == target {
+		return "#" + s.Anchor()
+	}
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(page)), filepath.FromSlash(target))
+	if err != nil {
+		return target + "#" + s.Anchor()
+	}
+	return filepath.ToSlash(rel) + "#" + s.Anchor()
+}
+
+// Resolve builds a symbol table from a set of documents & checks every type
+// reference in params, fields, return values & operators against it, returning
+// a diagnostic for each reference that doesn't resolve
+func (d Docs) Resolve() (*SymbolTable, []Diagnostic) {
+	t := &SymbolTable{
+		types:    map[string]*Symbol{},
+		funcs:    map[string]*Symbol{},
+		elements: map[interface{}]*Symbol{},
+		scopes:   map[interface{}]*Doc{},
+	}
+
+	var diags []Diagnostic
+	declare := func(s *Symbol, element interface{}) {
+		ns := t.types
+		if s.Kind == FunctionSymbol || s.Kind == MethodSymbol {
+			ns = t.funcs
+		}
+		if _, exists := ns[s.Name]; exists {
+			diags = append(diags, Diagnostic{
+				Pos:      s.Doc.Pos(),
+				Severity: Warning,
+				Message:  fmt.Sprintf("%s %q is declared more than once", s.Kind, s.Name),
+			})
+		} else {
+			ns[s.Name] = s
+		}
+		t.elements[element] = s
+		t.scopes[element] = s.Doc
+	}
+
+	Inspect(d, func(n Node, path Path) bool {
+		switch x := n.(type) {
+		case *Doc:
+			declare(&Symbol{Name: x.Name, Kind: ModuleSymbol, Doc: x}, x)
+		case *Type:
+			doc := path.Doc()
+			declare(&Symbol{Name: doc.Name + "." + x.Name, Kind: TypeSymbol, Doc: doc, Type: x}, x)
+		case *Function:
+			doc := path.Doc()
+			if typ := path.Type(); typ != nil {
+				declare(&Symbol{Name: doc.Name + "." + typ.Name + "." + x.Name(), Kind: MethodSymbol, Doc: doc, Type: typ, Function: x}, x)
+			} else {
+				declare(&Symbol{Name: doc.Name + "." + x.Name(), Kind: FunctionSymbol, Doc: doc, Function: x}, x)
+			}
+			// functions don't declare anything within them
+			return false
+		}
+		return true
+	})
+
+	check := func(path Path, element interface{}, pos Position, ref *TypeExpr, context string) {
+		doc := path.Doc()
+		t.scopes[element] = doc
+		var typeParams []string
+		if typ := path.Type(); typ != nil {
+			typeParams = typ.TypeParams
+		}
+		for _, name := range ref.Names() {
+			if !builtinTypes[name] && !contains(typeParams, name) && t.LookupType(name, doc) == nil {
+				diags = append(diags, Diagnostic{
+					Pos:      pos,
+					Severity: Warning,
+					Message:  fmt.Sprintf("%s: unknown type %q", context, name),
+				})
+			}
+		}
+	}
+
+	for _, doc := range d.Modules() {
+		for _, imp := range doc.Imports {
+			if _, ok := t.types[imp]; !ok {
+				diags = append(diags, Diagnostic{
+					Pos:      doc.importAt(imp),
+					Severity: Warning,
+					Message:  fmt.Sprintf("%s: unknown module %q imported", doc.Name, imp),
+				})
+			}
+		}
+	}
+
+	Inspect(d, func(n Node, path Path) bool {
+		switch x := n.(type) {
+		case *Function:
+			check(path, x, x.pos, x.ReturnTypeExpr(), t.elements[x].Name+" return")
+		case *Param:
+			check(path, x, x.pos, x.TypeExpr(), t.elements[path.Function()].Name+" param "+x.Name)
+		case *Field:
+			check(path, x, x.pos, x.TypeExpr(), t.elements[path.Type()].Name+" field "+x.Name)
+		case *Operator:
+			context := t.elements[path.Type()].Name + " operator " + x.Opr
+			left, right, result := x.TypeExprs()
+			check(path, x, x.pos, left, context)
+			check(path, x, x.pos, right, context)
+			check(path, x, x.pos, result, context)
+		}
+		return true
+	})
+
+	return t, diags
+}
+
+// LookupType finds a module or type by name. Unqualified names are first
+// resolved within the scope document, the modules it's nested in & the modules
+// it imports, if a scope is given
+func (t *SymbolTable) LookupType(name string, scope *Doc) *Symbol {
+	return lookup(t.types, name, scope)
+}
+
+// LookupFunction finds a function or method by name, eg: "time.now",
+// "duration.add". Unqualified names are first resolved within the scope
+// document, if one is given
+func (t *SymbolTable) LookupFunction(name string, scope *Doc) *Symbol {
+	return lookup(t.funcs, name, scope)
+}
+
+func lookup(ns map[string]*Symbol, name string, scope *Doc) *Symbol {
+	if scope != nil {
+		// submodules see the names of the modules they're nested in
+		mod := scope.Name
+		for {
+			if s, ok := ns[mod+"."+name]; ok {
+				return s
+			}
+			i := strings.LastIndex(mod, ".")
+			if i == -1 {
+				break
+			}
+			mod = mod[:i]
+		}
+		for _, imp := range scope.Imports {
+			if s, ok := ns[imp+"."+name]; ok {
+				return s
+			}
+		}
+	}
+	return ns[name]
+}
+
+// Anchor returns the URL fragment identifier for a document, type or
+// function. Strings are converted to anchors as-is
+func (t *SymbolTable) Anchor(v interface{}) string {
+	if name, ok := v.(string); ok {
+		return Anchor(name)
+	}
+	if s, ok := t.elements[v]; ok {
+		return s.Anchor()
+	}
+	return ""
+}
+
+// Link writes a type reference as markdown, linking each type name that
+// resolves to a symbol. It accepts a *Param, *Field or *Function, linking the
+// param type, field type or function return type respectively. Plain strings
+// are resolved without a scope
+func (t *SymbolTable) Link(v interface{}) string {
+	return t.rewrite(v, func(name string, s *Symbol, scope *Doc) string {
+		return fmt.Sprintf("[%s](%s)", name, t.Href(s, scope))
+	})
+}
+
+// Qualify writes a type reference with each type name that resolves to a
+// symbol replaced by the symbol's fully qualified name, eg: "[point,line]"
+// within the geo module becomes "[geo.point,geo.line]". It accepts the same
+// values as Link
+func (t *SymbolTable) Qualify(v interface{}) string {
+	return t.rewrite(v, func(name string, s *Symbol, scope *Doc) string {
+		return s.Name
+	})
+}
+
+// rewrite replaces each resolvable type name in the type reference of a
+// *Param, *Field, *Function or string with the result of calling fn
+func (t *SymbolTable) rewrite(v interface{}, fn func(name string, s *Symbol, scope *Doc) string) string {
+	switch x := v.(type) {
+	case *Param:
+		return t.rewriteRef(x.Type, t.scopes[x], fn)
+	case *Field:
+		return t.rewriteRef(x.Type, t.scopes[x], fn)
+	case *Function:
+		return t.rewriteRef(x.ReturnType(), t.scopes[x], fn)
+	case string:
+		return t.rewriteRef(x, nil, fn)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func (t *SymbolTable) rewriteRef(ref string, scope *Doc, fn func(name string, s *Symbol, scope *Doc) string) string {
+	buf := &bytes.Buffer{}
+	start := -1
+	flush := func(end int) {
+		if start == -1 {
+			return
+		}
+		name := ref[start:end]
+		if s := t.LookupType(name, scope); s != nil {
+			buf.WriteString(fn(name, s, scope))
+		} else {
+			buf.WriteString(name)
+		}
+		start = -1
+	}
+
+	for i, ch := range ref {
+		if isNameRune(ch) {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+		buf.WriteRune(ch)
+	}
+	flush(len(ref))
+	return buf.String()
+}
+
+func isNameRune(ch rune) bool {
+	return ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9' || ch == '_' || ch == '.'
+}
@@ -0,0 +1,119 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const resolveText = `
+outline: geo
+  import:
+    time
+    space
+  functions:
+    point(lat,lng float) point
+      params:
+        lat float
+        lng float
+    within(geomA,geomB) bool
+      params:
+        geomA [point,line,polygon]
+        geomB circle
+  types:
+    point
+      methods:
+        buffer(x int) polygon
+        travel(d time.duration) point
+      fields:
+        x float
+      operators:
+        point - point = time.duration
+    line
+    polygon
+
+outline: time
+  types:
+    duration
+      fields:
+        hours float
+        parent clock`
+
+func TestResolve(t *testing.T) {
+	docs, err := Parse(strings.NewReader(resolveText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table, diags := docs.Resolve()
+	var msgs []string
+	for _, d := range diags {
+		msgs = append(msgs, d.String())
+	}
+	// diagnostics are positioned at the line with the unknown reference
+	expect := []string{
+		`5:5: warning: geo: unknown module "space" imported`,
+		`14:9: warning: geo.within param geomB: unknown type "circle"`,
+		`32:9: warning: time.duration field parent: unknown type "clock"`,
+	}
+	if diff := cmp.Diff(expect, msgs); diff != "" {
+		t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+	}
+
+	if s := table.LookupType("point", docs[0]); s == nil || s.Kind != TypeSymbol || s.Name != "geo.point" {
+		t.Errorf("expected point to resolve to type geo.point in the geo scope, got: %#v", s)
+	}
+	if s := table.LookupType("point", docs[1]); s != nil {
+		t.Errorf("expected point not to resolve in the time scope, got: %#v", s)
+	}
+	if s := table.LookupFunction("point.buffer", docs[0]); s == nil || s.Kind != MethodSymbol || s.Name != "geo.point.buffer" {
+		t.Errorf("expected point.buffer to resolve to method geo.point.buffer, got: %#v", s)
+	}
+	if s := table.LookupFunction("geo.point", nil); s == nil || s.Kind != FunctionSymbol {
+		t.Errorf("expected geo.point to resolve to a function, got: %#v", s)
+	}
+
+	geomA := docs[0].Functions[1].Params[0]
+	link := "[[point](#type-geo-point),[line](#type-geo-line),[polygon](#type-geo-polygon)]"
+	if got := table.Link(geomA); got != link {
+		t.Errorf("link mismatch. expected: %q, got: %q", link, got)
+	}
+
+	travel := docs[0].Types[0].Methods[1]
+	if got := table.Link(travel); got != "[point](#type-geo-point)" {
+		t.Errorf("return link mismatch. got: %q", got)
+	}
+	if got := table.Anchor(travel); got != "method-geo-point-travel" {
+		t.Errorf("anchor mismatch. got: %q", got)
+	}
+}
+
+func TestResolveSubmodules(t *testing.T) {
+	docs, err := Parse(strings.NewReader(modulesText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table, diags := docs.Resolve()
+	var msgs []string
+	for _, d := range diags {
+		msgs = append(msgs, d.Message)
+	}
+	expect := []string{`re.compile return: unknown type "regex"`}
+	if diff := cmp.Diff(expect, msgs); diff != "" {
+		t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+	}
+
+	if s := table.LookupFunction("http.server.mux.handle", nil); s == nil || s.Doc != docs[0].Submodules[1].Submodules[0] {
+		t.Errorf("expected handle to be declared in http.server.mux, got: %#v", s)
+	}
+	// submodules resolve names declared in the modules they're nested in
+	client := docs[0].Submodules[0]
+	if got := table.Qualify(client.Types[0].Fields[0]); got != "http.response" {
+		t.Errorf("expected response to resolve within http.client to http.response, got: %q", got)
+	}
+	if s := table.LookupType("conn", docs[0]); s != nil {
+		t.Errorf("expected conn not to resolve in the http scope, got: %#v", s)
+	}
+}
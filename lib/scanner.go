@@ -6,40 +6,65 @@ import (
 	"strings"
 )
 
-// newScanner allocates a scanner from an io.Reader
-func newScanner(r io.Reader) *scanner {
-	return &scanner{r: bufio.NewReader(r)}
+// newScanner allocates a scanner from an io.Reader. filename is recorded in
+// the position of each token, and line numbers begin after lineOffset lines
+func newScanner(r io.Reader, filename string, lineOffset int) *scanner {
+	return &scanner{
+		r:         bufio.NewReader(r),
+		pos:       Position{Filename: filename, Line: lineOffset + 1, Col: 1},
+		lineStart: true,
+	}
 }
 
 // scanner tokenizes an input stream
-// TODO(b5): set position properly for errors
 type scanner struct {
 	r *bufio.Reader
 
 	// scanning state
-	tok               Token
-	text              strings.Builder
-	line, col, offset int
-	readNewline       bool
-	err               error
+	text        strings.Builder
+	pos         Position // position of the next rune to be read
+	start       Position // position of the first rune in the current token
+	last        Position // position before the last read rune, used by unread
+	lineStart   bool
+	afterIndent bool // the last token was the indentation of a line
+	readNewline bool
+
+	// custom section keywords, scanned as section tokens
+	sections map[string]*Section
 }
 
 // Scan reads one token from the input stream
 func (s *scanner) Scan() Token {
-	inText := false
 	s.text.Reset()
+	s.start = s.pos
 
 	if s.readNewline {
 		s.readNewline = false
 		return s.newTok(NewlineTok)
 	}
 
+	// leading whitespace is emitted as a single indent token that carries the
+	// raw indentation text. The parser decides how many levels it represents
+	lineStart := s.lineStart || s.afterIndent
+	s.afterIndent = false
+	if s.lineStart {
+		s.lineStart = false
+		if tok, ok := s.scanIndent(); ok {
+			s.afterIndent = true
+			return tok
+		}
+	}
+
+	if lineStart && s.atComment() {
+		return s.scanComment()
+	}
+
 	for {
 		ch := s.read()
 
 		switch ch {
 		case eof:
-			if inText {
+			if s.text.Len() > 0 {
 				s.readNewline = true
 				return s.newTok(TextTok)
 			}
@@ -48,71 +73,101 @@ func (s *scanner) Scan() Token {
 		case '\r':
 			continue
 		case '\n':
-			s.line++
-			if inText {
+			s.lineStart = true
+			if s.text.Len() > 0 {
 				s.readNewline = true
 				return s.newTok(TextTok)
 			}
 			return s.newTok(NewlineTok)
-		case '\t':
-			return s.newTok(IndentTok)
 		case ':':
-			switch s.text.String() {
-			case "path":
-				return s.newTok(PathTok)
-			case "outline":
-				return s.newTok(DocumentTok)
-			case "functions":
-				return s.newTok(FunctionsTok)
-			case "methods":
-				return s.newTok(MethodsTok)
-			case "types":
-				return s.newTok(TypesTok)
-			case "fields":
-				return s.newTok(FieldsTok)
-			case "operators":
-				return s.newTok(OperatorsTok)
-			case "params":
-				return s.newTok(ParamsTok)
-			case "return":
-				return s.newTok(ReturnTok)
-			case "code":
-				return s.newTok(CodeTok)
-			case "examples":
-				return s.newTok(ExamplesTok)
-			default:
-				s.text.WriteRune(':')
+			if t, ok := keywords[s.text.String()]; ok {
+				return s.newTok(t)
 			}
-		case ' ':
-			s.text.WriteRune(' ')
-			if s.text.String() == "  " {
-				return s.newTok(IndentTok)
+			if _, ok := s.sections[s.text.String()]; ok {
+				return s.newTok(SectionTok)
 			}
+			s.text.WriteRune(':')
 		default:
 			s.text.WriteRune(ch)
-			if !inText {
-				inText = true
-			}
 		}
 	}
 }
 
+// atComment reports whether the text that follows begins a comment
+func (s *scanner) atComment() bool {
+	prefix, _ := s.r.Peek(2)
+	return isComment(string(prefix))
+}
+
+// scanComment reads the rest of the line into a comment token
+func (s *scanner) scanComment() Token {
+	for {
+		ch := s.read()
+		switch ch {
+		case eof:
+			s.readNewline = true
+			return s.newTok(CommentTok)
+		case '\r':
+			continue
+		case '\n':
+			s.lineStart = true
+			s.readNewline = true
+			return s.newTok(CommentTok)
+		}
+		s.text.WriteRune(ch)
+	}
+}
+
+// scanIndent reads any tabs & spaces at the start of a line into an indent
+// token. ok is false if the line has no leading whitespace
+func (s *scanner) scanIndent() (tok Token, ok bool) {
+	for {
+		ch := s.read()
+		if ch != ' ' && ch != '\t' {
+			s.unread()
+			break
+		}
+		s.text.WriteRune(ch)
+	}
+
+	if s.text.Len() == 0 {
+		return tok, false
+	}
+	return Token{Type: IndentTok, Text: s.text.String(), Pos: s.start}, true
+}
+
 // read reads the next rune from the buffered reader.
 // Returns the rune(0) if an error occurs (or io.EOF is returned).
 func (s *scanner) read() rune {
-	ch, _, err := s.r.ReadRune()
+	ch, size, err := s.r.ReadRune()
 	if err != nil {
 		return eof
 	}
+
+	s.last = s.pos
+	s.pos.Offset += size
+	if ch == '\n' {
+		s.pos.Line++
+		s.pos.Col = 1
+	} else {
+		s.pos.Col++
+	}
 	return ch
 }
 
+// unread places the previously read rune back on the reader
+func (s *scanner) unread() {
+	if err := s.r.UnreadRune(); err == nil {
+		s.pos = s.last
+	}
+}
+
 // newTok creates a new token from current scanner state
 func (s *scanner) newTok(t TokenType) Token {
 	return Token{
 		Type: t,
 		Text: strings.TrimSpace(s.text.String()),
-		Pos:  Position{Line: s.line, Col: s.col, Offset: s.offset},
+		Pos:  s.start,
 	}
 }
 
@@ -0,0 +1,134 @@
+package lib
+
+import "strings"
+
+// search entry weights. Entries that name a whole module or a top-level
+// function or type rank above their members
+const (
+	moduleWeight   = 5
+	functionWeight = 4
+	typeWeight     = 4
+	methodWeight   = 3
+	fieldWeight    = 2
+	exampleWeight  = 1
+)
+
+// snippetLength is the longest snippet written to a search entry, in bytes
+const snippetLength = 160
+
+// SearchIndex is a list of searchable entries with the field boosts a client
+// side search library should use. It's laid out to be handed to lunr or
+// elasticlunr directly:
+//
+//	const idx = lunr(function () {
+//	  this.ref(index.ref)
+//	  Object.entries(index.fields).forEach(([f, boost]) => this.field(f, { boost }))
+//	  index.documents.forEach(d => this.add(d, { boost: d.weight }))
+//	})
+type SearchIndex struct {
+	Ref       string         `json:"ref"`
+	Fields    map[string]int `json:"fields"`
+	Documents []*SearchEntry `json:"documents"`
+}
+
+// SearchEntry is a single searchable element of a document
+type SearchEntry struct {
+	// ID uniquely identifies the entry within the index
+	ID string `json:"id"`
+	// Kind is one of module, function, method, type, field or example
+	Kind string `json:"kind"`
+	// Name is the qualified name of the element, eg: "geo.point.buffer"
+	Name string `json:"name"`
+	// Module is the name of the document that declares the element
+	Module string `json:"module"`
+	// URL links to the element's anchor
+	URL string `json:"url"`
+	// Snippet is a short, single-line summary for search results
+	Snippet string `json:"snippet"`
+	// Body is all searchable text of the element
+	Body string `json:"body"`
+	// Weight ranks the entry against other entries matching a search
+	Weight int `json:"weight"`
+}
+
+// SearchIndex builds a search index with an entry for each module, function,
+// method, type, field & example in docs. URLs are written with Href, so they
+// point to separate files when pages are set with SetPages. Fields & examples
+// don't have anchors of their own, and link to the type or function they
+// belong to
+func (t *SymbolTable) SearchIndex(docs Docs) *SearchIndex {
+	idx := &SearchIndex{
+		Ref: "id",
+		Fields: map[string]int{
+			"name":    10,
+			"snippet": 2,
+			"body":    1,
+		},
+	}
+
+	add := func(kind, name string, s *Symbol, desc Description, extra string, weight int) {
+		e := &SearchEntry{
+			ID:      Anchor(kind + " " + name),
+			Kind:    kind,
+			Name:    name,
+			Snippet: snippet(desc.Inline()),
+			Body:    strings.TrimSpace(desc.Inline() + " " + extra),
+			Weight:  weight,
+		}
+		if s != nil {
+			e.Module = s.Doc.Name
+			e.URL = t.Href(s, nil)
+		}
+		idx.Documents = append(idx.Documents, e)
+	}
+
+	addFunc := func(kind string, fn *Function, weight int) {
+		s := t.elements[fn]
+		if s == nil {
+			return
+		}
+		var params []string
+		for _, p := range fn.Params {
+			params = append(params, strings.TrimSpace(p.Name+" "+p.Type+" "+p.Description.Inline()))
+		}
+		add(kind, s.Name, s, fn.Description, fn.Signature+" "+strings.Join(params, " "), weight)
+		for _, eg := range fn.Examples {
+			add("example", s.Name+" "+eg.Name, s, eg.Description, eg.Code, exampleWeight)
+		}
+	}
+
+	for _, doc := range docs.Modules() {
+		add("module", doc.Name, t.elements[doc], doc.Description, "", moduleWeight)
+		for _, fn := range doc.Functions {
+			addFunc("function", fn, functionWeight)
+		}
+		for _, typ := range doc.Types {
+			s := t.elements[typ]
+			if s == nil {
+				continue
+			}
+			add("type", s.Name, s, typ.Description, "", typeWeight)
+			for _, f := range typ.Fields {
+				add("field", s.Name+"."+f.Name, s, f.Description, f.Type, fieldWeight)
+			}
+			for _, m := range typ.Methods {
+				addFunc("method", m, methodWeight)
+			}
+		}
+	}
+
+	return idx
+}
+
+// snippet shortens text to at most snippetLength bytes, breaking at a word
+// boundary & marking truncated text with an ellipsis
+func snippet(text string) string {
+	if len(text) <= snippetLength {
+		return text
+	}
+	cut := strings.LastIndex(text[:snippetLength], " ")
+	if cut <= 0 {
+		cut = snippetLength
+	}
+	return strings.TrimRight(text[:cut], " ,.;:") + "..."
+}
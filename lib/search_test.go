@@ -0,0 +1,63 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSearchIndex(t *testing.T) {
+	docs, err := Parse(strings.NewReader(`
+outline: geo
+  geo works with geographic shapes
+  functions:
+    point(lat,lng float) point
+      create a point
+      params:
+        lat float
+          latitude in degrees
+      examples:
+        origin
+          a point at the origin
+          code:
+            geo.point(0, 0)
+  types:
+    point
+      a location on the globe
+      fields:
+        x float
+          longitude
+      methods:
+        buffer(r float) polygon
+          grow a point into a circle`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, _ := docs.Resolve()
+	table.SetPages(func(*Doc) string { return "geo.html" })
+
+	idx := table.SearchIndex(docs)
+	expect := []*SearchEntry{
+		{ID: "module-geo", Kind: "module", Name: "geo", Module: "geo", URL: "geo.html#module-geo", Snippet: "geo works with geographic shapes", Body: "geo works with geographic shapes", Weight: moduleWeight},
+		{ID: "function-geo-point", Kind: "function", Name: "geo.point", Module: "geo", URL: "geo.html#function-geo-point", Snippet: "create a point", Body: "create a point point(lat,lng float) point lat float latitude in degrees", Weight: functionWeight},
+		{ID: "example-geo-point-origin", Kind: "example", Name: "geo.point origin", Module: "geo", URL: "geo.html#function-geo-point", Snippet: "a point at the origin", Body: "a point at the origin geo.point(0, 0)", Weight: exampleWeight},
+		{ID: "type-geo-point", Kind: "type", Name: "geo.point", Module: "geo", URL: "geo.html#type-geo-point", Snippet: "a location on the globe", Body: "a location on the globe", Weight: typeWeight},
+		{ID: "field-geo-point-x", Kind: "field", Name: "geo.point.x", Module: "geo", URL: "geo.html#type-geo-point", Snippet: "longitude", Body: "longitude float", Weight: fieldWeight},
+		{ID: "method-geo-point-buffer", Kind: "method", Name: "geo.point.buffer", Module: "geo", URL: "geo.html#method-geo-point-buffer", Snippet: "grow a point into a circle", Body: "grow a point into a circle buffer(r float) polygon", Weight: methodWeight},
+	}
+	if diff := cmp.Diff(expect, idx.Documents); diff != "" {
+		t.Errorf("search entries mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	long := strings.Repeat("word ", 40)
+	got := snippet(long)
+	if len(got) > snippetLength+3 || !strings.HasSuffix(got, "word...") {
+		t.Errorf("expected snippet to be cut at a word boundary, got: %q", got)
+	}
+	if got := snippet("short"); got != "short" {
+		t.Errorf("expected short text to be unchanged, got: %q", got)
+	}
+}
@@ -0,0 +1,251 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// SectionKind determines how the lines of a custom section are read
+type SectionKind int
+
+const (
+	// TextSection reads a section as a single string, joining lines with
+	// spaces: "complexity: O(n)"
+	TextSection SectionKind = iota
+	// ListSection reads one string for each line of a section, starting with
+	// any text on the keyword line, as a []string
+	ListSection
+	// KeyValueSection reads "key: value" lines indented beneath the keyword as
+	// a map[string]string
+	KeyValueSection
+)
+
+// String implements the stringer interface for SectionKind
+func (k SectionKind) String() string {
+	switch k {
+	case TextSection:
+		return "text"
+	case ListSection:
+		return "list"
+	case KeyValueSection:
+		return "key/value"
+	default:
+		return "unknown"
+	}
+}
+
+// Scope is a set of elements a custom section may be written in
+type Scope int
+
+const (
+	// InDocument allows a section directly beneath an "outline:" line
+	InDocument Scope = 1 << iota
+	// InFunction allows a section in functions & methods
+	InFunction
+	// InType allows a section in types
+	InType
+)
+
+// String lists the elements of a scope, eg: "a document or a function"
+func (s Scope) String() string {
+	var names []string
+	for _, el := range []struct {
+		scope Scope
+		name  string
+	}{{InDocument, "a document"}, {InFunction, "a function"}, {InType, "a type"}} {
+		if s&el.scope != 0 {
+			names = append(names, el.name)
+		}
+	}
+	return strings.Join(names, " or ")
+}
+
+// Section declares a custom section keyword, like "permissions:". Values read
+// from custom sections are stored in the Extensions of the element they're
+// written in, keyed by section name
+type Section struct {
+	// Name is the section keyword, without the trailing colon
+	Name string
+	Kind SectionKind
+	// In is the set of elements the section may be written in
+	In Scope
+}
+
+// validate checks a section can be registered
+func (s *Section) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("section name is required")
+	}
+	for _, r := range s.Name {
+		if !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return fmt.Errorf("section name %q may only contain letters, numbers, dashes & underscores", s.Name)
+		}
+	}
+	if _, ok := keywords[s.Name]; ok {
+		return fmt.Errorf("section name %q is a built-in keyword", s.Name)
+	}
+	if s.In == 0 {
+		return fmt.Errorf("section %q must be allowed in a document, function or type", s.Name)
+	}
+	return nil
+}
+
+var (
+	sectionsMu sync.RWMutex
+	// sections is the registry of custom sections available to all parsing
+	sections = map[string]*Section{}
+)
+
+// RegisterSection adds a custom section to every parse, replacing any section
+// registered with the same name
+func RegisterSection(s *Section) error {
+	if err := s.validate(); err != nil {
+		return err
+	}
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+	sections[s.Name] = s
+	return nil
+}
+
+// Sections adds custom sections to a single parse. Sections given as an option
+// take precedence over registered sections with the same name
+func Sections(s ...*Section) Option { return sectionsOpt(s) }
+
+type sectionsOpt []*Section
+
+func (o sectionsOpt) apply(cfg *config) error {
+	if cfg.sections == nil {
+		cfg.sections = map[string]*Section{}
+	}
+	for _, s := range o {
+		if err := s.validate(); err != nil {
+			return err
+		}
+		cfg.sections[s.Name] = s
+	}
+	return nil
+}
+
+// sectionSet combines registered sections with those of an option
+func (cfg config) sectionSet() map[string]*Section {
+	sectionsMu.RLock()
+	defer sectionsMu.RUnlock()
+	set := make(map[string]*Section, len(sections)+len(cfg.sections))
+	for name, s := range sections {
+		set[name] = s
+	}
+	for name, s := range cfg.sections {
+		set[name] = s
+	}
+	return set
+}
+
+// readSection reads the custom section that begins with tok into ext, if the
+// section may be written in scope. Misplaced sections are skipped
+func (p *parser) readSection(tok Token, scope Scope, ext *map[string]interface{}) {
+	sec := p.s.sections[tok.Text]
+	if sec.In&scope == 0 {
+		p.reportf(tok.Pos, "%s: isn't allowed in %s, only in %s", tok.Text, scope, sec.In)
+		p.skip(p.indent)
+		return
+	}
+
+	var value interface{}
+	switch sec.Kind {
+	case TextSection:
+		value = strings.Join(p.readList(p.indent), " ")
+	case ListSection:
+		value = p.readList(p.indent)
+	case KeyValueSection:
+		value = p.readKeyValues(tok, p.indent)
+	}
+
+	if *ext == nil {
+		*ext = map[string]interface{}{}
+	}
+	// sections written more than once are combined
+	switch prev := (*ext)[sec.Name].(type) {
+	case string:
+		value = strings.TrimSpace(prev + " " + value.(string))
+	case []string:
+		value = append(prev, value.([]string)...)
+	case map[string]string:
+		for k, v := range value.(map[string]string) {
+			prev[k] = v
+		}
+		value = prev
+	}
+	(*ext)[sec.Name] = value
+}
+
+// readKeyValues reads "key: value" lines indented beneath a section keyword
+func (p *parser) readKeyValues(kw Token, baseIndent int) map[string]string {
+	values := map[string]string{}
+	line := p.line
+	for {
+		tok := p.scan()
+		if tok.Type == eofTok || (p.line != line && p.indent <= baseIndent) {
+			p.unscan()
+			return values
+		}
+
+		switch {
+		case p.line == line:
+			p.reportf(tok.Pos, "unexpected text %q after %s:, write values on lines indented beneath it", tok.Text, kw.Text)
+		case tok.Type > KeywordBegin && tok.Type < KeywordEnd:
+			// keys that are also keywords are scanned as keyword tokens, with the
+			// value as a separate token on the same line
+			key, keyLine := tok.Text, p.line
+			values[key] = ""
+			if next := p.scan(); next.Type == TextTok && p.line == keyLine {
+				values[key] = next.Text
+			} else {
+				p.unscan()
+			}
+		default:
+			i := strings.Index(tok.Text, ":")
+			if i == -1 {
+				p.reportf(tok.Pos, "expected a \"key: value\" line in %s:, got %q", kw.Text, tok.Text)
+				continue
+			}
+			values[strings.TrimSpace(tok.Text[:i])] = strings.TrimSpace(tok.Text[i+1:])
+		}
+	}
+}
+
+// writeExtensions writes extension values as custom sections, sorted by name
+func writeExtensions(buf *bytes.Buffer, ext map[string]interface{}, indent, prefix string) {
+	names := make([]string, 0, len(ext))
+	for name := range ext {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		switch v := ext[name].(type) {
+		case string:
+			buf.WriteString(indent + name + ": " + v + "\n")
+		case []string:
+			buf.WriteString(indent + name + ":\n")
+			for _, item := range v {
+				buf.WriteString(indent + prefix + item + "\n")
+			}
+		case map[string]string:
+			buf.WriteString(indent + name + ":\n")
+			keys := make([]string, 0, len(v))
+			for k := range v {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				buf.WriteString(strings.TrimRight(indent+prefix+k+": "+v[k], " ") + "\n")
+			}
+		default:
+			buf.WriteString(fmt.Sprintf("%s%s: %v\n", indent, name, v))
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testSections = Sections(
+	&Section{Name: "permissions", Kind: KeyValueSection, In: InDocument | InFunction},
+	&Section{Name: "complexity", Kind: TextSection, In: InFunction},
+	&Section{Name: "see_also", Kind: ListSection, In: InFunction | InType},
+)
+
+const sectionText = `outline: fs
+  fs reads files
+  permissions:
+    read: all
+    write: admin
+  functions:
+    open(path) file
+      opens a file
+      complexity: O(1)
+      permissions:
+        read: owner
+        return: file
+      see_also: close
+        stat
+  types:
+    file
+      see_also:
+        fs.open
+`
+
+func TestParseSections(t *testing.T) {
+	docs, err := Parse(strings.NewReader(sectionText), testSections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := docs[0]
+	if doc.Description != "fs reads files" {
+		t.Errorf("section lines leaked into description: %q", doc.Description)
+	}
+
+	expect := map[string]interface{}{
+		"permissions": map[string]string{"read": "all", "write": "admin"},
+	}
+	if diff := cmp.Diff(expect, doc.Extensions); diff != "" {
+		t.Errorf("document extensions mismatch (-want +got):\n%s", diff)
+	}
+
+	expect = map[string]interface{}{
+		"complexity":  "O(1)",
+		"permissions": map[string]string{"read": "owner", "return": "file"},
+		"see_also":    []string{"close", "stat"},
+	}
+	if diff := cmp.Diff(expect, doc.Functions[0].Extensions); diff != "" {
+		t.Errorf("function extensions mismatch (-want +got):\n%s", diff)
+	}
+	if doc.Functions[0].Description != "opens a file" {
+		t.Errorf("unexpected function description: %q", doc.Functions[0].Description)
+	}
+
+	expect = map[string]interface{}{"see_also": []string{"fs.open"}}
+	if diff := cmp.Diff(expect, doc.Types[0].Extensions); diff != "" {
+		t.Errorf("type extensions mismatch (-want +got):\n%s", diff)
+	}
+
+	// without the option sections are read as description text
+	docs, err = Parse(strings.NewReader(sectionText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs[0].Extensions != nil {
+		t.Errorf("expected no extensions, got: %v", docs[0].Extensions)
+	}
+}
+
+func TestSectionOutput(t *testing.T) {
+	docs, err := Parse(strings.NewReader(sectionText), testSections)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := template.Must(template.New("").Parse(`{{ range .Functions }}{{ .Name }} {{ .Extensions.complexity }} {{ index .Extensions.permissions "read" }}{{ end }}`))
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, docs[0]); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "open O(1) owner" {
+		t.Errorf("template output mismatch: %q", got)
+	}
+
+	data, err := json.Marshal(docs[0].Functions[0].Extensions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectJSON := `{"complexity":"O(1)","permissions":{"read":"owner","return":"file"},"see_also":["close","stat"]}`
+	if string(data) != expectJSON {
+		t.Errorf("json mismatch. expected:\n%s\ngot:\n%s", expectJSON, data)
+	}
+
+	// extensions survive a round trip through outline text
+	data, err = MarshalNode(docs, "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Parse(bytes.NewReader(data), testSections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(docs, got, ignoreUnexported); diff != "" {
+		t.Errorf("round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSectionDiagnostics(t *testing.T) {
+	text := `outline: fs
+  complexity: O(n)
+  functions:
+    open(path) file
+      permissions: all
+        read
+        write: admin
+      permisions:
+        read: all`
+
+	docs, err := Parse(strings.NewReader(text), testSections)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, d := range docs[0].Diagnostics() {
+		got = append(got, d.String())
+	}
+	expect := []string{
+		"2:3: warning: complexity: isn't allowed in a document, only in a function",
+		`5:19: warning: unexpected text "all" after permissions:, write values on lines indented beneath it`,
+		`6:9: warning: expected a "key: value" line in permissions:, got "read"`,
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = Parse(strings.NewReader(text), testSections, Strict())
+	if err == nil || !strings.Contains(err.Error(), `unknown keyword "permisions:", did you mean "permissions:"?`) {
+		t.Errorf("expected strict parsing to suggest a custom section, got: %v", err)
+	}
+}
+
+func TestRegisterSection(t *testing.T) {
+	bad := []*Section{
+		{Name: "", In: InFunction},
+		{Name: "two words", In: InFunction},
+		{Name: "params", In: InFunction},
+		{Name: "nowhere"},
+	}
+	for _, s := range bad {
+		if err := RegisterSection(s); err == nil {
+			t.Errorf("expected registering %q to fail", s.Name)
+		}
+	}
+
+	if err := RegisterSection(&Section{Name: "since", Kind: TextSection, In: InFunction | InType}); err != nil {
+		t.Fatal(err)
+	}
+	defer delete(sections, "since")
+
+	docs, err := Parse(strings.NewReader("outline: fs\n  functions:\n    open()\n      since: 1.2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := docs[0].Functions[0].Extensions["since"]; got != "1.2" {
+		t.Errorf("expected registered section value, got: %v", got)
+	}
+}
+
+func TestRegisterSectionConcurrently(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		name := fmt.Sprintf("tag%d", i)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := RegisterSection(&Section{Name: name, Kind: TextSection, In: InFunction}); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if _, err := Parse(strings.NewReader("outline: fs\n  functions:\n    open()")); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+	for i := 0; i < 8; i++ {
+		delete(sections, fmt.Sprintf("tag%d", i))
+	}
+}
+
+func TestMergeExtensions(t *testing.T) {
+	a := map[string]interface{}{"since": "1.0", "tags": []string{"io"}}
+	b := map[string]interface{}{"since": "1.2", "owner": "fs"}
+
+	got, err := mergeExtensions(a, b, PreferFirst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := map[string]interface{}{"since": "1.0", "tags": []string{"io"}, "owner": "fs"}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("prefer first mismatch (-want +got):\n%s", diff)
+	}
+
+	if got, _ = mergeExtensions(a, b, PreferLast); got["since"] != "1.2" {
+		t.Errorf("expected last value to win, got: %v", got["since"])
+	}
+	if _, err := mergeExtensions(a, b, RequireEqual); err == nil {
+		t.Error("expected conflicting values to fail")
+	}
+	if a["owner"] != nil {
+		t.Error("merging must not modify its inputs")
+	}
+}
@@ -0,0 +1,561 @@
+package lib
+
+import (
+	"bytes"
+	goscanner "go/scanner"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LineKind classifies the lines of a syntax tree
+type LineKind int
+
+const (
+	// ForeignLine is text outside of any outline document
+	ForeignLine LineKind = iota
+	// BlankLine is an empty or whitespace-only line within a document
+	BlankLine
+	// CommentLine is a line within a document that begins with "#" or "//"
+	CommentLine
+	// KeywordLine is a line that begins with a section keyword, eg: "params:"
+	KeywordLine
+	// TextLine is any other line within a document
+	TextLine
+	// CodeLine is a line of a code block, either in a "code:" section or
+	// a fenced block within a description. Indentation of code lines is
+	// significant & comments aren't recognized within them
+	CodeLine
+)
+
+// String implements the stringer interface for LineKind
+func (k LineKind) String() string {
+	switch k {
+	case ForeignLine:
+		return "foreign"
+	case BlankLine:
+		return "blank"
+	case CommentLine:
+		return "comment"
+	case KeywordLine:
+		return "keyword"
+	case TextLine:
+		return "text"
+	case CodeLine:
+		return "code"
+	default:
+		return "unknown"
+	}
+}
+
+// SyntaxNode is a single line of source text, along with any lines nested
+// beneath it. The text of a node's line is split into a margin, indentation,
+// content & line ending. Concatenating those four always reproduces the line
+// exactly
+type SyntaxNode struct {
+	Kind LineKind
+	// Keyword is the keyword that begins keyword lines
+	Keyword TokenType
+	// Pos is the position of the first byte of the line
+	Pos Position
+	// Margin is text that precedes the outline on every line, like the "// "
+	// of a go comment
+	Margin string
+	// Indent is the whitespace that begins the line after any margin
+	Indent string
+	// Text is the content of the line, without indentation or line ending
+	Text string
+	// EOL is the line ending, one of "\n", "\r\n", or "" for the last line of
+	// input without a trailing newline
+	EOL string
+	// Children holds the lines nested beneath this one in source order.
+	// blank & comment lines belong to the same parent as the line that
+	// follows them. those that end a document are foreign lines
+	Children []*SyntaxNode
+}
+
+// Value returns the text that follows the keyword of a keyword line, eg:
+// "geo" for "outline: geo". Other lines return their text
+func (n *SyntaxNode) Value() string {
+	if n.Kind != KeywordLine {
+		return n.Text
+	}
+	return strings.TrimSpace(n.Text[len(n.Keyword.String())+1:])
+}
+
+// SyntaxTree is a lossless, line-oriented concrete syntax tree of a source
+// file containing outline documents. Lines within documents are nested by
+// indentation, while text outside of documents is kept as foreign lines at
+// the top level. Writing a tree with Bytes reproduces the source byte for byte
+type SyntaxTree struct {
+	Filename string
+	Nodes    []*SyntaxNode
+}
+
+// ParseSyntax reads source text into a syntax tree. Files with a ".go"
+// extension are read as go source, where outlines are written in comments.
+// Comments are found with the go scanner, so comment markers in strings
+// aren't mistaken for comments. In line comments the comment marker & a single
+// following space are kept in the margin of each line, while lines of block
+// comments have no margin. Lines that hold any go code are foreign lines
+func ParseSyntax(src []byte, opts ...Option) (*SyntaxTree, error) {
+	cfg, err := parseOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &SyntaxTree{Filename: cfg.filename}
+	b := &syntaxBuilder{tree: t, unit: cfg.indentWidth, desc: -1}
+	if filepath.Ext(cfg.filename) == ".go" {
+		b.goSource, b.goLines = true, goCommentLines(src)
+	}
+	pos := Position{Filename: cfg.filename, Line: cfg.lineOffset + 1, Col: 1}
+	for len(src) > 0 {
+		line := src
+		if i := bytes.IndexByte(src, '\n'); i != -1 {
+			line = src[:i+1]
+		}
+		b.add(string(line), pos)
+		pos.Line++
+		pos.Offset += len(line)
+		src = src[len(line):]
+	}
+	b.closeDocument()
+	return t, nil
+}
+
+// goComment classifies a line of go source by the comment it holds
+type goComment int
+
+const (
+	// goCode lines hold go code, possibly alongside a comment
+	goCode goComment = iota
+	// goLineComment lines hold nothing but a line comment
+	goLineComment
+	// goBlockComment lines are within a block comment, after the line that
+	// opens it & before the line that closes it
+	goBlockComment
+)
+
+// goCommentLines scans go source for comments, classifying each line that
+// holds only comment text by the offset the line begins at
+func goCommentLines(src []byte) map[int]goComment {
+	lines := map[int]goComment{}
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(src))
+	var s goscanner.Scanner
+	// source that doesn't scan cleanly is read as far as it can be
+	s.Init(file, src, nil, goscanner.ScanComments)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		if tok != token.COMMENT {
+			continue
+		}
+		start := file.Offset(pos)
+		lineStart := bytes.LastIndexByte(src[:start], '\n') + 1
+		if strings.HasPrefix(lit, "//") {
+			if len(bytes.TrimLeft(src[lineStart:start], " \t")) == 0 {
+				lines[lineStart] = goLineComment
+			}
+			continue
+		}
+
+		end := len(src)
+		if i := bytes.Index(src[start:], []byte("*/")); i != -1 {
+			end = start + i
+		}
+		// lines after the first line of the comment, up to its last line
+		for i := start; ; {
+			nl := bytes.IndexByte(src[i:end], '\n')
+			if nl == -1 {
+				break
+			}
+			i += nl + 1
+			if bytes.IndexByte(src[i:end], '\n') != -1 {
+				lines[i] = goBlockComment
+			}
+		}
+	}
+	return lines
+}
+
+// syntaxBuilder nests lines into a tree as they're read
+type syntaxBuilder struct {
+	tree     *SyntaxTree
+	goSource bool
+	// goLines classifies the comment lines of go source by offset
+	goLines map[int]goComment
+	// unit is the configured number of spaces per indentation level
+	unit int
+	// indent measures indentation levels within the current document, as the
+	// parser does
+	indent indentation
+	// stack holds the open lines of the current document, outermost first
+	stack []*SyntaxNode
+	// levels holds the indentation level of each line in stack
+	levels []int
+	// trivia are blank & comment lines waiting for the next line to decide
+	// where they belong
+	trivia []*SyntaxNode
+	// code is the line that opened the current code block, if any
+	code      *SyntaxNode
+	codeLevel int
+	fence     bool
+	// desc is the level of the first line of the current description, or -1
+	// outside of descriptions. "#" lines within descriptions are headings
+	desc int
+}
+
+func (b *syntaxBuilder) add(raw string, pos Position) {
+	n := &SyntaxNode{Pos: pos}
+	content := raw
+	switch {
+	case strings.HasSuffix(content, "\r\n"):
+		n.EOL = "\r\n"
+	case strings.HasSuffix(content, "\n"):
+		n.EOL = "\n"
+	}
+	content = content[:len(content)-len(n.EOL)]
+
+	if b.goSource {
+		switch b.goLines[pos.Offset] {
+		case goLineComment:
+			trimmed := strings.TrimLeft(content, " \t")
+			margin := len(content) - len(trimmed) + 2
+			if strings.HasPrefix(content[margin:], " ") {
+				margin++
+			}
+			n.Margin, content = content[:margin], content[margin:]
+		case goBlockComment:
+			// lines of a block comment are read as written
+		default:
+			// go code ends any open document
+			b.closeDocument()
+			n.Kind, n.Text = ForeignLine, content
+			b.tree.Nodes = append(b.tree.Nodes, n)
+			return
+		}
+	}
+
+	text := strings.TrimLeft(content, " \t")
+	n.Indent, n.Text = content[:len(content)-len(text)], text
+	kind := scanLine(text)
+
+	if len(b.stack) == 0 {
+		if kind == DocumentTok {
+			b.flush(nil)
+			n.Kind, n.Keyword = KeywordLine, DocumentTok
+			b.tree.Nodes = append(b.tree.Nodes, n)
+			b.indent.begin(n.Indent, b.unit)
+			b.push(n, 0)
+			b.desc = -1
+			return
+		}
+		n.Kind = ForeignLine
+		b.tree.Nodes = append(b.tree.Nodes, n)
+		return
+	}
+
+	if kind == eofTok {
+		n.Kind = BlankLine
+		b.trivia = append(b.trivia, n)
+		return
+	}
+
+	// the parser measures the lines it reads: all lines but comments, which
+	// are read within code & as headings of descriptions
+	heading := b.desc >= 0 && strings.HasPrefix(text, "#")
+	level := 0
+	if kind != CommentTok || b.code != nil || heading {
+		level = b.indent.level(b.indent.relative(n.Indent))
+	}
+
+	// lines of an open code block
+	if b.code != nil {
+		if b.fence && level >= b.codeLevel {
+			n.Kind = CodeLine
+			b.flush(b.code)
+			b.code.Children = append(b.code.Children, n)
+			if strings.HasPrefix(text, "```") {
+				b.code, b.fence = nil, false
+			}
+			return
+		}
+		if !b.fence && level > b.codeLevel {
+			n.Kind = CodeLine
+			b.flush(b.code)
+			b.code.Children = append(b.code.Children, n)
+			return
+		}
+		b.code, b.fence = nil, false
+	}
+
+	if kind == CommentTok && !(heading && level >= b.desc) {
+		n.Kind = CommentLine
+		b.trivia = append(b.trivia, n)
+		return
+	}
+
+	// find the parent of this line, closing any lines indented as far as it
+	for len(b.stack) > 0 && b.levels[len(b.levels)-1] >= level {
+		b.stack, b.levels = b.stack[:len(b.stack)-1], b.levels[:len(b.levels)-1]
+	}
+	if len(b.stack) == 0 {
+		// the line ends the document
+		b.closeDocument()
+		b.add(raw, pos)
+		return
+	}
+
+	parent := b.stack[len(b.stack)-1]
+	// indented "outline:" lines begin submodules of the enclosing document
+	if kind > KeywordBegin && kind < KeywordEnd {
+		n.Kind, n.Keyword = KeywordLine, kind
+	} else {
+		n.Kind = TextLine
+	}
+	b.flush(parent)
+	parent.Children = append(parent.Children, n)
+	b.push(n, level)
+
+	// text nested beneath documents or other text is description text
+	if n.Kind == TextLine && (parent.Kind == TextLine || parent.Keyword == DocumentTok) {
+		if b.desc == -1 {
+			b.desc = level
+		}
+	} else {
+		b.desc = -1
+	}
+
+	switch {
+	case n.Keyword == CodeTok:
+		b.code, b.codeLevel = n, level
+	case n.Kind == TextLine && strings.HasPrefix(text, "```"):
+		// fenced code is nested beneath the opening fence
+		b.code, b.codeLevel, b.fence = n, level, true
+	}
+}
+
+// scanLine reads the first token of a line of outline text with the scanner
+// documents are parsed with. Blank lines scan to eofTok
+func scanLine(text string) TokenType {
+	return newScanner(strings.NewReader(text), "", 0).Scan().Type
+}
+
+// push opens a line that following lines may nest beneath
+func (b *syntaxBuilder) push(n *SyntaxNode, level int) {
+	b.stack = append(b.stack, n)
+	b.levels = append(b.levels, level)
+}
+
+// flush adds waiting trivia lines to a parent, or the top level of the tree
+// when parent is nil
+func (b *syntaxBuilder) flush(parent *SyntaxNode) {
+	if parent == nil {
+		for _, n := range b.trivia {
+			if len(b.stack) == 0 {
+				n.Kind = ForeignLine
+			}
+		}
+		b.tree.Nodes = append(b.tree.Nodes, b.trivia...)
+	} else {
+		parent.Children = append(parent.Children, b.trivia...)
+	}
+	b.trivia = nil
+}
+
+// closeDocument ends the current document. Waiting blank & comment lines
+// follow the document as foreign lines
+func (b *syntaxBuilder) closeDocument() {
+	b.stack, b.levels = nil, nil
+	b.code, b.fence = nil, false
+	b.desc = -1
+	b.flush(nil)
+}
+
+// columns measures leading whitespace, counting tabs as four spaces
+func columns(ws string) int {
+	return len(ws) + 3*strings.Count(ws, "\t")
+}
+
+// Bytes writes the tree back to source text
+func (t *SyntaxTree) Bytes() []byte {
+	buf := &bytes.Buffer{}
+	t.Inspect(func(n *SyntaxNode, depth int) bool {
+		buf.WriteString(n.Margin + n.Indent + n.Text + n.EOL)
+		return true
+	})
+	return buf.Bytes()
+}
+
+// Inspect calls f for each line of the tree in source order, along with the
+// depth of the line within its document. Documents & foreign lines are at
+// depth zero. Children of a line are skipped when f returns false
+func (t *SyntaxTree) Inspect(f func(n *SyntaxNode, depth int) bool) {
+	var visit func(nodes []*SyntaxNode, depth int)
+	visit = func(nodes []*SyntaxNode, depth int) {
+		for _, n := range nodes {
+			if f(n, depth) {
+				visit(n.Children, depth+1)
+			}
+		}
+	}
+	visit(t.Nodes, 0)
+}
+
+// Text returns the outline text of the tree: the source with the margin
+// of each line removed & foreign lines left blank, so line numbers of the
+// text match the source
+func (t *SyntaxTree) Text() []byte {
+	buf := &bytes.Buffer{}
+	t.Inspect(func(n *SyntaxNode, depth int) bool {
+		if n.Kind != ForeignLine {
+			buf.WriteString(n.Indent + n.Text)
+		}
+		buf.WriteString(n.EOL)
+		return true
+	})
+	return buf.Bytes()
+}
+
+// Docs parses the outline documents of the tree into the outline model.
+// Positions of parsed elements refer to lines of the source the tree was read
+// from
+func (t *SyntaxTree) Docs(opts ...Option) (Docs, error) {
+	line := 0
+	if len(t.Nodes) > 0 {
+		line = t.Nodes[0].Pos.Line - 1
+	}
+	opts = append([]Option{Filename(t.Filename), LineOffset(line)}, opts...)
+	return Parse(bytes.NewReader(t.Text()), opts...)
+}
+
+// SortDocuments orders documents by path & name, as Docs.Sort does. Only
+// documents separated by nothing but blank lines change places, so comments
+// above a document & code around documents in go source stay put
+func (t *SyntaxTree) SortDocuments() {
+	isDoc := func(n *SyntaxNode) bool { return n.Kind == KeywordLine && n.Keyword == DocumentTok }
+	for i := 0; i < len(t.Nodes); i++ {
+		if !isDoc(t.Nodes[i]) {
+			continue
+		}
+		// collect a run of documents & the positions they're written in
+		var docs []*SyntaxNode
+		var slots []int
+		j := i
+		for ; j < len(t.Nodes); j++ {
+			n := t.Nodes[j]
+			if isDoc(n) {
+				docs, slots = append(docs, n), append(slots, j)
+			} else if n.Kind != ForeignLine || strings.TrimSpace(n.Text) != "" {
+				break
+			}
+		}
+
+		// the last line of input may not have a line ending, which moves to
+		// the line that's last after sorting
+		last := lastNode(docs[len(docs)-1])
+		missing := last.EOL == ""
+		if missing {
+			last.EOL = "\n"
+			if first := lastNode(docs[0]); first.EOL != "" {
+				last.EOL = first.EOL
+			}
+		}
+		sort.SliceStable(docs, func(a, b int) bool { return docKey(docs[a]) < docKey(docs[b]) })
+		for k, n := range docs {
+			t.Nodes[slots[k]] = n
+		}
+		if missing {
+			lastNode(docs[len(docs)-1]).EOL = ""
+		}
+		i = j
+	}
+}
+
+// docKey sorts document lines by the document's path & name
+func docKey(doc *SyntaxNode) string {
+	path := ""
+	for _, c := range doc.Children {
+		if c.Kind == KeywordLine && c.Keyword == PathTok {
+			path = strings.TrimSpace(c.Value())
+		}
+	}
+	return path + strings.TrimSpace(doc.Value())
+}
+
+// lastNode returns the last line nested within n, or n itself
+func lastNode(n *SyntaxNode) *SyntaxNode {
+	for len(n.Children) > 0 {
+		n = n.Children[len(n.Children)-1]
+	}
+	return n
+}
+
+// Format rewrites the indentation of every line within outline documents,
+// indenting each level of nesting with prefix. Documents keep the indentation
+// of their "outline:" line, code keeps its indentation relative to the block
+// it belongs to, & trailing whitespace is removed. Foreign lines are left
+// untouched
+func (t *SyntaxTree) Format(prefix string) {
+	for _, n := range t.Nodes {
+		if n.Kind == KeywordLine && n.Keyword == DocumentTok {
+			n.Text = strings.TrimRight(n.Text, " \t")
+			formatChildren(n, n.Indent, n.Indent+prefix, prefix)
+		}
+	}
+}
+
+// formatChildren re-indents the lines nested beneath parent. orig is the
+// indentation parent had before formatting
+func formatChildren(parent *SyntaxNode, orig, indent, prefix string) {
+	var code []*SyntaxNode
+	for _, n := range parent.Children {
+		switch n.Kind {
+		case BlankLine:
+			n.Margin = strings.TrimRight(n.Margin, " \t")
+			n.Indent, n.Text = "", ""
+		case CodeLine:
+			code = append(code, n)
+		default:
+			was := n.Indent
+			n.Indent = indent
+			n.Text = strings.TrimRight(n.Text, " \t")
+			formatChildren(n, was, indent+prefix, prefix)
+		}
+	}
+	if len(code) == 0 {
+		return
+	}
+	if parent.Keyword == CodeTok {
+		formatCode(code, "", indent)
+	} else {
+		// fenced code is written relative to its opening fence
+		formatCode(code, orig, parent.Indent)
+	}
+}
+
+// formatCode re-indents a block of code lines to indent, keeping indentation
+// relative to base or the least indented line, whichever is less indented
+func formatCode(lines []*SyntaxNode, base, indent string) {
+	if base == "" {
+		base = lines[0].Indent
+	}
+	for _, n := range lines {
+		if columns(n.Indent) < columns(base) {
+			base = n.Indent
+		}
+	}
+	for _, n := range lines {
+		rel := strings.TrimPrefix(n.Indent, base)
+		if !strings.HasPrefix(n.Indent, base) {
+			rel = strings.Repeat(" ", columns(n.Indent)-columns(base))
+		}
+		n.Indent = indent + rel
+		n.Text = strings.TrimRight(n.Text, " \t")
+	}
+}
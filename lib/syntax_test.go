@@ -0,0 +1,289 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const syntaxText = `leading text that isn't an outline
+outline: geo
+    # about geo
+    geo does geography
+
+    ` + "```" + `
+    x = 1
+      y = 2
+    ` + "```" + `
+    functions:
+        // about point
+        point(x, y) point
+            make a point
+            examples:
+                basic
+                    code:
+                        # not a comment
+                        p = point(1, 2)
+                          indented
+
+# after the document
+`
+
+func TestSyntaxRoundTrip(t *testing.T) {
+	cases := []struct {
+		filename string
+		text     string
+	}{
+		{"", ""},
+		{"", syntaxText},
+		{"", "outline: crlf\r\n  description\r\n\r\n  # comment\r\n"},
+		{"", "outline: no_newline\n  functions:\n    fn()"},
+		{"geo.go", "package geo\n\n// outline: geo\n//   desc\n//\n//   # comment\nfunc init() {\n\tx := 1\n}\n"},
+		{"time.go", "package time\n\n/*\noutline: time\n  functions:\n    now() time\n*/\nfunc now() {}\n"},
+	}
+
+	for i, c := range cases {
+		tree, err := ParseSyntax([]byte(c.text), Filename(c.filename))
+		if err != nil {
+			t.Fatalf("case %d: %s", i, err)
+		}
+		if got := string(tree.Bytes()); got != c.text {
+			t.Errorf("case %d: round trip mismatch. expected:\n%q\ngot:\n%q", i, c.text, got)
+		}
+	}
+}
+
+func TestSyntaxKinds(t *testing.T) {
+	tree, err := ParseSyntax([]byte(syntaxText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	tree.Inspect(func(n *SyntaxNode, depth int) bool {
+		got = append(got, n.Kind.String())
+		return true
+	})
+	expect := []string{
+		"foreign",
+		"keyword", "comment", "text", "blank", "text", "code", "code", "code",
+		"keyword", "comment", "text", "text", "keyword", "text", "keyword", "code", "code", "code",
+		"foreign", "foreign",
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("line kinds mismatch (-want +got):\n%s", diff)
+	}
+
+	doc := tree.Nodes[1]
+	if doc.Keyword != DocumentTok || doc.Value() != "geo" {
+		t.Errorf("expected document line for geo, got: %s %q", doc.Keyword, doc.Value())
+	}
+	if doc.Pos.Line != 2 {
+		t.Errorf("expected document on line 2, got: %d", doc.Pos.Line)
+	}
+}
+
+func TestSyntaxDescriptionHeadings(t *testing.T) {
+	text := `outline: geo
+  # about geo
+  geo does geography
+  ## Details
+    # nested
+  // a comment
+  functions:
+    # about point
+    point(x, y)
+`
+	tree, err := ParseSyntax([]byte(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	tree.Inspect(func(n *SyntaxNode, depth int) bool {
+		got = append(got, n.Kind.String())
+		return true
+	})
+	expect := []string{"keyword", "comment", "text", "text", "text", "comment", "keyword", "comment", "text"}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("line kinds mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSyntaxFormat(t *testing.T) {
+	expect := `leading text that isn't an outline
+outline: geo
+  # about geo
+  geo does geography
+
+  ` + "```" + `
+  x = 1
+    y = 2
+  ` + "```" + `
+  functions:
+    // about point
+    point(x, y) point
+      make a point
+      examples:
+        basic
+          code:
+            # not a comment
+            p = point(1, 2)
+              indented
+
+# after the document
+`
+	tree, err := ParseSyntax([]byte(syntaxText))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := tree.Docs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree.Format("  ")
+	got := string(tree.Bytes())
+	if expect != got {
+		dmp := differ.DiffMain(expect, got, true)
+		t.Errorf("format mismatch:\n%s", differ.DiffPrettyText(dmp))
+	}
+
+	// formatting must not change the meaning of documents
+	after, err := tree.Docs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(before, after, ignoreUnexported); diff != "" {
+		t.Errorf("formatting changed documents (-want +got):\n%s", diff)
+	}
+}
+
+func TestSyntaxGoSource(t *testing.T) {
+	src := `package geo
+
+// outline: geo
+//     geo does geography
+//
+//     functions:
+//         # about point
+//         point(x, y) point
+func init() {
+	// not an outline
+	x := 1
+}
+`
+	expect := `package geo
+
+// outline: geo
+// 	geo does geography
+//
+// 	functions:
+// 		# about point
+// 		point(x, y) point
+func init() {
+	// not an outline
+	x := 1
+}
+`
+	tree, err := ParseSyntax([]byte(src), Filename("geo.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := tree.Docs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || len(docs[0].Functions) != 1 {
+		t.Fatalf("expected one document with one function, got: %#v", docs)
+	}
+	if docs[0].Functions[0].Pos().Line != 8 {
+		t.Errorf("expected function position to refer to source line 8, got: %d", docs[0].Functions[0].Pos().Line)
+	}
+
+	tree.Format("\t")
+	if got := string(tree.Bytes()); got != expect {
+		dmp := differ.DiffMain(expect, got, true)
+		t.Errorf("format mismatch:\n%s", differ.DiffPrettyText(dmp))
+	}
+}
+
+func TestSyntaxGoBlockComment(t *testing.T) {
+	src := "package time\n\n/*\noutline: time\n    functions:\n        now() time\n*/\nfunc now() {}\n"
+	expect := "package time\n\n/*\noutline: time\n  functions:\n    now() time\n*/\nfunc now() {}\n"
+
+	tree, err := ParseSyntax([]byte(src), Filename("time.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, err := tree.Docs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || len(docs[0].Functions) != 1 {
+		t.Fatalf("expected one document with one function, got: %#v", docs)
+	}
+
+	tree.Format("  ")
+	if got := string(tree.Bytes()); got != expect {
+		t.Errorf("format mismatch. expected:\n%s\ngot:\n%s", expect, got)
+	}
+}
+
+func TestSyntaxGoStrings(t *testing.T) {
+	// comment markers in strings don't begin comments, & outlines in strings
+	// aren't documents
+	src := "package geo\n\nvar a = `/*\noutline: fake\n\tfunctions:\n\t\tf()\n`\n\nvar b = \"// outline: fake\" // outline: fake\n\n// outline: geo\n//   functions:\n//     point()\nfunc init() {}\n"
+	tree, err := ParseSyntax([]byte(src), Filename("geo.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	docs, err := tree.Docs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 || docs[0].Name != "geo" {
+		t.Fatalf("expected only the geo document, got: %#v", docs)
+	}
+
+	tree.Format("\t")
+	expect := strings.Replace(src, "//   functions:\n//     point()", "// \tfunctions:\n// \t\tpoint()", 1)
+	if got := string(tree.Bytes()); got != expect {
+		t.Errorf("format mismatch. expected:\n%s\ngot:\n%s", expect, got)
+	}
+}
+
+func TestSyntaxIndentLevels(t *testing.T) {
+	// a tab is one level, as it is to the parser, however many spaces a level
+	// is written with
+	src := "outline: geo\n  functions:\n\tpoint()\n"
+	tree, err := ParseSyntax([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	depths := map[string]int{}
+	tree.Inspect(func(n *SyntaxNode, depth int) bool {
+		depths[n.Text] = depth
+		return true
+	})
+	if depths["point()"] != depths["functions:"] {
+		t.Errorf("expected point() at the depth of functions:, got: %v", depths)
+	}
+}
+
+func TestSyntaxSortDocuments(t *testing.T) {
+	src := "outline: time\n  functions:\n    now()\n\noutline: geo\n  types:\n    point\n# about zoo\noutline: zoo\n\noutline: art\n  types:\n    brush"
+	expect := "outline: geo\n  types:\n    point\n\noutline: time\n  functions:\n    now()\n# about zoo\noutline: art\n  types:\n    brush\n\noutline: zoo"
+
+	tree, err := ParseSyntax([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree.SortDocuments()
+	if got := string(tree.Bytes()); got != expect {
+		dmp := differ.DiffMain(expect, got, true)
+		t.Errorf("sort mismatch:\n%s", differ.DiffPrettyText(dmp))
+	}
+}
@@ -1,10 +1,25 @@
 package lib
 
+import (
+	"fmt"
+	"strings"
+)
+
 // Position of a token within the scan stream
 type Position struct {
+	Filename          string
 	Line, Col, Offset int
 }
 
+// String formats a position as "filename:line:col", omitting the filename
+// when it isn't known
+func (p Position) String() string {
+	if p.Filename != "" {
+		return fmt.Sprintf("%s:%d:%d", p.Filename, p.Line, p.Col)
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
+}
+
 // Token is a recognized token from the outlineline lexicon
 type Token struct {
 	Type TokenType
@@ -27,12 +42,15 @@ const (
 
 	// LiteralBegin marks the beginning of literal tokens in the token enumeration
 	LiteralBegin
-	// IndentTok is a tab character "\t" or two consecutive spaces"  "
+	// IndentTok is the run of tabs & spaces that begins a line
 	IndentTok
 	// NewlineTok is a line break
 	NewlineTok
 	// TextTok is a token for arbitrary text
 	TextTok
+	// CommentTok is a line that begins with "#" or "//". Comments are ignored
+	// outside of code blocks
+	CommentTok
 	// LiteralEnd marks the end of literal tokens in the token enumeration
 	LiteralEnd
 
@@ -46,6 +64,10 @@ const (
 	ExamplesTok
 	// PathTok is the "path:" token
 	PathTok
+	// ImportTok is the "import:" token
+	ImportTok
+	// IncludeTok is the "include:" token
+	IncludeTok
 	// FunctionsTok is the "functions:" token
 	FunctionsTok
 	// ParamsTok is the "params:" token
@@ -60,22 +82,113 @@ const (
 	MethodsTok
 	// OperatorsTok is the "operators:" token
 	OperatorsTok
+	// ModulesTok is the "modules:" token
+	ModulesTok
+	// SectionTok is a custom section keyword. The token text is the section
+	// name
+	SectionTok
 	// KeywordEnd marks the end of keyword tokens in the token enumeration
 	KeywordEnd
 )
 
+// keywords maps the text that precedes a colon to keyword tokens
+var keywords = map[string]TokenType{
+	"path":      PathTok,
+	"import":    ImportTok,
+	"include":   IncludeTok,
+	"outline":   DocumentTok,
+	"functions": FunctionsTok,
+	"methods":   MethodsTok,
+	"types":     TypesTok,
+	"fields":    FieldsTok,
+	"operators": OperatorsTok,
+	"params":    ParamsTok,
+	"return":    ReturnTok,
+	"code":      CodeTok,
+	"examples":  ExamplesTok,
+	"modules":   ModulesTok,
+}
+
+// suggestKeyword returns the keyword or custom section name closest in
+// spelling to word, or an empty string if none is similar enough to be a
+// likely typo
+func suggestKeyword(word string, sections ...string) string {
+	word = strings.ToLower(word)
+	best, min := "", 3
+	consider := func(kw string) {
+		if d := editDistance(word, strings.ToLower(kw)); d < min || (d == min && kw < best) {
+			best, min = kw, d
+		}
+	}
+	for kw := range keywords {
+		consider(kw)
+	}
+	for _, name := range sections {
+		consider(name)
+	}
+	if min >= len(word) {
+		return ""
+	}
+	return best
+}
+
+// editDistance counts the insertions, deletions, substitutions & swaps of
+// adjacent bytes that turn a into b
+func editDistance(a, b string) int {
+	// rows of the distance matrix for the last two prefixes of a
+	prev2, prev, cur := make([]int, len(b)+1), make([]int, len(b)+1), make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = minInt(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+func minInt(n int, rest ...int) int {
+	for _, m := range rest {
+		if m < n {
+			n = m
+		}
+	}
+	return n
+}
+
+// isComment reports whether a line, stripped of indentation, is a comment
+func isComment(line string) bool {
+	return strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//")
+}
+
 func (t TokenType) String() string {
 	switch t {
 	case IndentTok:
-		return "tab"
+		return "indent"
 	case NewlineTok:
 		return "newline"
 	case TextTok:
 		return "text"
+	case CommentTok:
+		return "comment"
 	case DocumentTok:
 		return "outline"
 	case PathTok:
 		return "path"
+	case ImportTok:
+		return "import"
+	case IncludeTok:
+		return "include"
 	case MethodsTok:
 		return "methods"
 	case ExamplesTok:
@@ -94,6 +207,10 @@ func (t TokenType) String() string {
 		return "params"
 	case ReturnTok:
 		return "return"
+	case ModulesTok:
+		return "modules"
+	case SectionTok:
+		return "section"
 	default:
 		return "unknown"
 	}
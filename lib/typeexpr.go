@@ -0,0 +1,400 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TypeKind enumerates the kinds of type expression
+type TypeKind int
+
+const (
+	// NamedType is a reference to a type by name, eg: "int" or "geo.point".
+	// Named types may have type arguments: "tuple[int,string]" or "box[T]"
+	NamedType TypeKind = iota
+	// UnionType is any one of its members, written "[point,line]" or
+	// "point | line"
+	UnionType
+	// ListType is a list of its single argument, eg: "list[int]". Lists
+	// written without an argument hold values of any type
+	ListType
+	// DictType maps its first argument to its second, eg: "dict[string,int]"
+	DictType
+	// OptionalType is its single argument or None, eg: "int?"
+	OptionalType
+	// CallableType is a function that accepts its arguments & returns Result,
+	// eg: "callable(int, string) -> bool" or "callable[[int,string],bool]"
+	CallableType
+)
+
+// String implements the stringer interface for TypeKind
+func (k TypeKind) String() string {
+	switch k {
+	case NamedType:
+		return "named"
+	case UnionType:
+		return "union"
+	case ListType:
+		return "list"
+	case DictType:
+		return "dict"
+	case OptionalType:
+		return "optional"
+	case CallableType:
+		return "callable"
+	default:
+		return "unknown"
+	}
+}
+
+// TypeExpr is a parsed type expression, as written in params, fields & return
+// values:
+//
+//	name                    int, geo.point, T
+//	name[args]              tuple[int,string], box[T]
+//	list[type]              list[int]
+//	dict[key,value]         dict[string,int]
+//	[type,type...]          [point,line,polygon], a union of its members
+//	type | type             point | line, also a union
+//	type?                   int?, an optional value
+//	callable(args) -> type  callable(int, string) -> bool
+//	callable[[args],type]   callable[[int,string],bool]
+//	(type)                  (int | string)?, grouping
+type TypeExpr struct {
+	Kind TypeKind
+	// Name is the type name of named types, "list", "dict" or "callable" for
+	// lists, dicts & callables, and empty for unions & optionals
+	Name string
+	// Args are the type arguments of named types, the element of a list, key &
+	// value of a dict, members of a union, value of an optional, and params of
+	// a callable
+	Args []*TypeExpr
+	// Result is the return type of a callable, if declared
+	Result *TypeExpr
+}
+
+// String writes a type expression in canonical form, eg: "[point,line]" for
+// "point | line"
+func (t *TypeExpr) String() string {
+	if t == nil {
+		return ""
+	}
+	switch t.Kind {
+	case UnionType:
+		return "[" + joinTypes(t.Args) + "]"
+	case OptionalType:
+		if len(t.Args) == 1 {
+			return t.Args[0].String() + "?"
+		}
+	case CallableType:
+		if len(t.Args) == 0 && t.Result == nil {
+			return t.Name
+		}
+		s := t.Name + "(" + strings.Replace(joinTypes(t.Args), ",", ", ", -1) + ")"
+		if t.Result != nil {
+			s += " -> " + t.Result.String()
+		}
+		return s
+	}
+	if len(t.Args) > 0 {
+		return t.Name + "[" + joinTypes(t.Args) + "]"
+	}
+	return t.Name
+}
+
+func joinTypes(types []*TypeExpr) string {
+	strs := make([]string, len(types))
+	for i, t := range types {
+		strs[i] = t.String()
+	}
+	return strings.Join(strs, ",")
+}
+
+// Names lists the type names an expression refers to in the order they're
+// written, excluding "list", "dict" & "callable"
+func (t *TypeExpr) Names() (names []string) {
+	if t == nil {
+		return nil
+	}
+	if t.Kind == NamedType && t.Name != "..." {
+		names = append(names, t.Name)
+	}
+	for _, arg := range t.Args {
+		names = append(names, arg.Names()...)
+	}
+	return append(names, t.Result.Names()...)
+}
+
+// ParseType parses a type expression. An empty string parses to a nil
+// expression without error
+func ParseType(text string) (*TypeExpr, error) {
+	p := &typeParser{text: text}
+	p.next()
+	if p.tok == "" {
+		return nil, nil
+	}
+	t, err := p.union()
+	if err != nil {
+		return nil, err
+	}
+	if p.tok != "" {
+		return nil, p.errorf("unexpected %q", p.tok)
+	}
+	return t, nil
+}
+
+// typeParser reads type expressions one token at a time. Tokens are names,
+// "...", "->" & single punctuation characters
+type typeParser struct {
+	text     string
+	pos      int
+	tok      string
+	tokStart int
+}
+
+func (p *typeParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("type %q: %s at offset %d", p.text, fmt.Sprintf(format, args...), p.tokStart)
+}
+
+// next advances to the next token, setting tok to "" at the end of input
+func (p *typeParser) next() {
+	for p.pos < len(p.text) && (p.text[p.pos] == ' ' || p.text[p.pos] == '\t') {
+		p.pos++
+	}
+	p.tokStart = p.pos
+	switch {
+	case p.pos == len(p.text):
+		p.tok = ""
+		return
+	case strings.HasPrefix(p.text[p.pos:], "..."):
+		p.pos += 3
+	case strings.HasPrefix(p.text[p.pos:], "->"):
+		p.pos += 2
+	case isNameRune(rune(p.text[p.pos])):
+		for p.pos < len(p.text) && isNameRune(rune(p.text[p.pos])) {
+			p.pos++
+		}
+	default:
+		p.pos++
+	}
+	p.tok = p.text[p.tokStart:p.pos]
+}
+
+// expect consumes a token, failing if it isn't tok
+func (p *typeParser) expect(tok string) error {
+	if p.tok != tok {
+		if p.tok == "" {
+			return p.errorf("expected %q, found end of type", tok)
+		}
+		return p.errorf("expected %q, found %q", tok, p.tok)
+	}
+	p.next()
+	return nil
+}
+
+// union reads types separated by "|"
+func (p *typeParser) union() (*TypeExpr, error) {
+	t, err := p.postfix()
+	if err != nil || p.tok != "|" {
+		return t, err
+	}
+	u := &TypeExpr{Kind: UnionType, Args: []*TypeExpr{t}}
+	for p.tok == "|" {
+		p.next()
+		if t, err = p.postfix(); err != nil {
+			return nil, err
+		}
+		u.Args = append(u.Args, t)
+	}
+	return u, nil
+}
+
+// postfix reads a type followed by any number of optional markers
+func (p *typeParser) postfix() (*TypeExpr, error) {
+	t, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for p.tok == "?" {
+		p.next()
+		t = &TypeExpr{Kind: OptionalType, Args: []*TypeExpr{t}}
+	}
+	return t, nil
+}
+
+func (p *typeParser) primary() (*TypeExpr, error) {
+	switch {
+	case p.tok == "[":
+		p.next()
+		args, err := p.list("]")
+		if err != nil {
+			return nil, err
+		}
+		return &TypeExpr{Kind: UnionType, Args: args}, nil
+	case p.tok == "(":
+		p.next()
+		t, err := p.union()
+		if err != nil {
+			return nil, err
+		}
+		return t, p.expect(")")
+	case p.tok == "...":
+		p.next()
+		return &TypeExpr{Kind: NamedType, Name: "..."}, nil
+	case p.tok != "" && isNameRune(rune(p.tok[0])) && strings.Trim(p.tok, ".") != "":
+		name := p.tok
+		p.next()
+		switch name {
+		case "list", "dict":
+			return p.container(name)
+		case "callable":
+			return p.callable()
+		}
+		t := &TypeExpr{Kind: NamedType, Name: name}
+		if p.tok == "[" {
+			p.next()
+			args, err := p.list("]")
+			if err != nil {
+				return nil, err
+			}
+			t.Args = args
+		}
+		return t, nil
+	case p.tok == "":
+		return nil, p.errorf("expected a type, found end of type")
+	default:
+		return nil, p.errorf("expected a type, found %q", p.tok)
+	}
+}
+
+// container reads the optional type arguments of a list or dict
+func (p *typeParser) container(name string) (*TypeExpr, error) {
+	kind, want := ListType, 1
+	if name == "dict" {
+		kind, want = DictType, 2
+	}
+	t := &TypeExpr{Kind: kind, Name: name}
+	if p.tok != "[" {
+		return t, nil
+	}
+	start := p.tokStart
+	p.next()
+	args, err := p.list("]")
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != want {
+		p.tokStart = start
+		return nil, p.errorf("%s takes %d type arguments, found %d", name, want, len(args))
+	}
+	t.Args = args
+	return t, nil
+}
+
+// callable reads the params & result of a callable in either of the forms
+// "callable(args) -> result" or "callable[[args],result]"
+func (p *typeParser) callable() (t *TypeExpr, err error) {
+	t = &TypeExpr{Kind: CallableType, Name: "callable"}
+	switch p.tok {
+	case "(":
+		p.next()
+		if t.Args, err = p.list(")"); err != nil {
+			return nil, err
+		}
+		if p.tok == "->" {
+			p.next()
+			if t.Result, err = p.postfix(); err != nil {
+				return nil, err
+			}
+		}
+	case "[":
+		p.next()
+		if p.tok == "..." {
+			// any arguments
+			p.next()
+			t.Args = []*TypeExpr{{Kind: NamedType, Name: "..."}}
+		} else {
+			if err = p.expect("["); err != nil {
+				return nil, err
+			}
+			if t.Args, err = p.list("]"); err != nil {
+				return nil, err
+			}
+		}
+		if err = p.expect(","); err != nil {
+			return nil, err
+		}
+		if t.Result, err = p.union(); err != nil {
+			return nil, err
+		}
+		if err = p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// list reads comma-separated types up to a closing token, which is consumed
+func (p *typeParser) list(close string) (types []*TypeExpr, err error) {
+	if p.tok == close {
+		p.next()
+		return nil, nil
+	}
+	for {
+		t, err := p.union()
+		if err != nil {
+			return nil, err
+		}
+		types = append(types, t)
+		if p.tok != "," {
+			return types, p.expect(close)
+		}
+		p.next()
+	}
+}
+
+// TypeExpr parses the param's type, returning nil if the param has no type or
+// its type isn't a valid type expression. Types are parsed from Type each
+// time, so they always agree
+func (p *Param) TypeExpr() *TypeExpr {
+	t, _ := ParseType(p.Type)
+	return t
+}
+
+// TypeExpr parses the field's type, returning nil if the field has no type or
+// its type isn't a valid type expression
+func (f *Field) TypeExpr() *TypeExpr {
+	t, _ := ParseType(f.Type)
+	return t
+}
+
+// ReturnTypeExpr parses the function's return type, returning nil if the
+// function doesn't declare one or it isn't a valid type expression
+func (f *Function) ReturnTypeExpr() *TypeExpr {
+	t, _ := ParseType(f.ReturnType())
+	return t
+}
+
+// TypeExprs parses the operand & result types of the operator, returning nil
+// for operands it doesn't have & types that aren't valid type expressions
+func (o *Operator) TypeExprs() (left, right, result *TypeExpr) {
+	left, _ = ParseType(o.Left)
+	right, _ = ParseType(o.Right)
+	result, _ = ParseType(o.Result)
+	return left, right, result
+}
+
+// splitTypeParams separates the type parameters from a type declaration, eg:
+// "box[T]" is the type "box" with the parameter "T"
+func splitTypeParams(decl string) (name string, params []string) {
+	open := strings.Index(decl, "[")
+	if open == -1 || !strings.HasSuffix(decl, "]") {
+		return decl, nil
+	}
+	for _, param := range strings.Split(decl[open+1:len(decl)-1], ",") {
+		if param = strings.TrimSpace(param); param != "" {
+			params = append(params, param)
+		}
+	}
+	return strings.TrimSpace(decl[:open]), params
+}
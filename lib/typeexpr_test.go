@@ -0,0 +1,158 @@
+package lib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseType(t *testing.T) {
+	named := func(name string, args ...*TypeExpr) *TypeExpr {
+		return &TypeExpr{Kind: NamedType, Name: name, Args: args}
+	}
+
+	cases := []struct {
+		text   string
+		expect *TypeExpr
+		str    string
+	}{
+		{"", nil, ""},
+		{"int", named("int"), "int"},
+		{"geo.point", named("geo.point"), "geo.point"},
+		{"[point,line, polygon]", &TypeExpr{Kind: UnionType, Args: []*TypeExpr{named("point"), named("line"), named("polygon")}}, "[point,line,polygon]"},
+		{"point | line", &TypeExpr{Kind: UnionType, Args: []*TypeExpr{named("point"), named("line")}}, "[point,line]"},
+		{"list", &TypeExpr{Kind: ListType, Name: "list"}, "list"},
+		{"list[int]", &TypeExpr{Kind: ListType, Name: "list", Args: []*TypeExpr{named("int")}}, "list[int]"},
+		{"dict[string, list[int]]", &TypeExpr{Kind: DictType, Name: "dict", Args: []*TypeExpr{
+			named("string"),
+			{Kind: ListType, Name: "list", Args: []*TypeExpr{named("int")}},
+		}}, "dict[string,list[int]]"},
+		{"int?", &TypeExpr{Kind: OptionalType, Args: []*TypeExpr{named("int")}}, "int?"},
+		{"(int | string)?", &TypeExpr{Kind: OptionalType, Args: []*TypeExpr{
+			{Kind: UnionType, Args: []*TypeExpr{named("int"), named("string")}},
+		}}, "[int,string]?"},
+		{"callable", &TypeExpr{Kind: CallableType, Name: "callable"}, "callable"},
+		{"callable(int, string) -> bool", &TypeExpr{Kind: CallableType, Name: "callable", Args: []*TypeExpr{named("int"), named("string")}, Result: named("bool")}, "callable(int, string) -> bool"},
+		{"callable[[int,string],bool]", &TypeExpr{Kind: CallableType, Name: "callable", Args: []*TypeExpr{named("int"), named("string")}, Result: named("bool")}, "callable(int, string) -> bool"},
+		{"callable[..., any]", &TypeExpr{Kind: CallableType, Name: "callable", Args: []*TypeExpr{named("...")}, Result: named("any")}, "callable(...) -> any"},
+		{"box[T]", named("box", named("T")), "box[T]"},
+		{"tuple[int, ...]", named("tuple", named("int"), named("...")), "tuple[int,...]"},
+	}
+
+	for _, c := range cases {
+		got, err := ParseType(c.text)
+		if err != nil {
+			t.Errorf("%q: %s", c.text, err)
+			continue
+		}
+		if diff := cmp.Diff(c.expect, got); diff != "" {
+			t.Errorf("%q: expression mismatch (-want +got):\n%s", c.text, diff)
+		}
+		if got.String() != c.str {
+			t.Errorf("%q: expected string %q, got %q", c.text, c.str, got.String())
+		}
+	}
+}
+
+func TestParseTypeErrors(t *testing.T) {
+	cases := []struct {
+		text, err string
+	}{
+		{"list[int", `type "list[int": expected "]", found end of type at offset 8`},
+		{"dict[int]", `type "dict[int]": dict takes 2 type arguments, found 1 at offset 4`},
+		{"int string", `type "int string": unexpected "string" at offset 4`},
+		{"[point,]", `type "[point,]": expected a type, found "]" at offset 7`},
+		{"a | ", `type "a | ": expected a type, found end of type at offset 4`},
+		{"callable[int, bool]", `type "callable[int, bool]": expected "[", found "int" at offset 9`},
+	}
+	for _, c := range cases {
+		_, err := ParseType(c.text)
+		if err == nil || err.Error() != c.err {
+			t.Errorf("%q: expected error %q, got: %v", c.text, c.err, err)
+		}
+	}
+}
+
+func TestTypeExprNames(t *testing.T) {
+	typ, err := ParseType("callable(dict[string, geo.point], ...) -> [line,polygon]?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{"string", "geo.point", "line", "polygon"}
+	if diff := cmp.Diff(expect, typ.Names()); diff != "" {
+		t.Errorf("names mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestModelTypeExprs(t *testing.T) {
+	text := `outline: store
+  functions:
+    get(key string) box[T]?
+      params:
+        key string
+  types:
+    box[T]
+      fields:
+        value T
+        on_change callable(T) -> none
+        tags list[string
+`
+	docs, err := Parse(strings.NewReader(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := docs[0]
+
+	if got := doc.Functions[0].ReturnTypeExpr(); got == nil || got.Kind != OptionalType || got.Args[0].Name != "box" {
+		t.Errorf("unexpected return type: %#v", got)
+	}
+	if got := doc.Functions[0].Params[0].TypeExpr(); got == nil || got.Name != "string" {
+		t.Errorf("unexpected param type: %#v", got)
+	}
+	// parsed types follow changes to the model
+	param := doc.Functions[0].Params[0]
+	param.Type = "list[box]"
+	if got := param.TypeExpr(); got == nil || got.Kind != ListType {
+		t.Errorf("expected the changed param type to be parsed, got: %#v", got)
+	}
+
+	box := doc.Types[0]
+	if box.Name != "box" || !cmp.Equal(box.TypeParams, []string{"T"}) {
+		t.Errorf("expected generic type box with param T, got: %q %v", box.Name, box.TypeParams)
+	}
+	if box.Fields[1].Type != "callable(T) -> none" || box.Fields[1].TypeExpr().Kind != CallableType {
+		t.Errorf("expected callable field type, got: %q", box.Fields[1].Type)
+	}
+	if box.Fields[2].TypeExpr() != nil {
+		t.Error("expected malformed type not to parse")
+	}
+
+	var msgs []string
+	for _, d := range doc.Diagnostics() {
+		msgs = append(msgs, d.String())
+	}
+	expect := []string{`11:9: warning: field tags: type "list[string": expected "]", found end of type at offset 11`}
+	if diff := cmp.Diff(expect, msgs); diff != "" {
+		t.Errorf("diagnostics mismatch (-want +got):\n%s", diff)
+	}
+
+	// type parameters resolve within their type
+	_, diags := docs.Resolve()
+	msgs = nil
+	for _, d := range diags {
+		msgs = append(msgs, d.Message)
+	}
+	expect = []string{`store.get return: unknown type "T"`}
+	if diff := cmp.Diff(expect, msgs); diff != "" {
+		t.Errorf("resolve diagnostics mismatch (-want +got):\n%s", diff)
+	}
+
+	data, err := MarshalNode(box, "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "box[T]\n") {
+		t.Errorf("expected type parameters to be written, got:\n%s", data)
+	}
+}
@@ -0,0 +1,167 @@
+package lib
+
+// Node is an element of the outline model: Docs, *Doc, *Function, *Param,
+// *Type, *Field, *Operator or *Example
+type Node interface {
+	node()
+}
+
+func (Docs) node()      {}
+func (*Doc) node()      {}
+func (*Function) node() {}
+func (*Param) node()    {}
+func (*Type) node()     {}
+func (*Field) node()    {}
+func (*Operator) node() {}
+func (*Example) node()  {}
+
+// Path lists the ancestors of a node during a walk, starting with the node
+// the walk began at & ending with the node's parent
+type Path []Node
+
+// Parent returns the direct parent of a node, or nil for the root of a walk
+func (p Path) Parent() Node {
+	if len(p) == 0 {
+		return nil
+	}
+	return p[len(p)-1]
+}
+
+// Doc returns the closest document enclosing a node, if any
+func (p Path) Doc() *Doc {
+	for i := len(p) - 1; i >= 0; i-- {
+		if d, ok := p[i].(*Doc); ok {
+			return d
+		}
+	}
+	return nil
+}
+
+// Type returns the closest type enclosing a node, if any
+func (p Path) Type() *Type {
+	for i := len(p) - 1; i >= 0; i-- {
+		if t, ok := p[i].(*Type); ok {
+			return t
+		}
+	}
+	return nil
+}
+
+// Function returns the closest function or method enclosing a node, if any
+func (p Path) Function() *Function {
+	for i := len(p) - 1; i >= 0; i-- {
+		if fn, ok := p[i].(*Function); ok {
+			return fn
+		}
+	}
+	return nil
+}
+
+// A Visitor's Visit method is invoked for each node encountered by Walk,
+// along with the path of ancestors leading to it. If the result visitor w
+// is not nil, Walk visits each of the children of node with the visitor w,
+// followed by a call of w.Visit(nil, path)
+type Visitor interface {
+	Visit(node Node, path Path) (w Visitor)
+}
+
+// Walk traverses an outline model in depth-first order: It starts by calling
+// v.Visit(node, path); node must not be nil. If the visitor w returned by
+// v.Visit(node, path) is not nil, Walk is invoked recursively with visitor w
+// for each of the non-nil children of node, followed by a call of
+// w.Visit(nil, path).
+//
+// Children are visited in declaration order: documents visit functions, types,
+// then submodules, functions visit params then examples, and types visit methods,
+// fields, then operators
+func Walk(v Visitor, node Node) {
+	walk(v, node, nil)
+}
+
+func walk(v Visitor, node Node, path Path) {
+	if v = v.Visit(node, path); v == nil {
+		return
+	}
+
+	// copy on append, so paths handed to visitors aren't overwritten by
+	// sibling nodes
+	children := append(path[:len(path):len(path)], node)
+	for _, child := range Children(node) {
+		walk(v, child, children)
+	}
+
+	v.Visit(nil, path)
+}
+
+// Children lists the non-nil direct children of a node in declaration order
+func Children(node Node) (children []Node) {
+	switch n := node.(type) {
+	case Docs:
+		for _, d := range n {
+			if d != nil {
+				children = append(children, d)
+			}
+		}
+	case *Doc:
+		for _, fn := range n.Functions {
+			if fn != nil {
+				children = append(children, fn)
+			}
+		}
+		for _, t := range n.Types {
+			if t != nil {
+				children = append(children, t)
+			}
+		}
+		for _, mod := range n.Submodules {
+			if mod != nil {
+				children = append(children, mod)
+			}
+		}
+	case *Function:
+		for _, p := range n.Params {
+			if p != nil {
+				children = append(children, p)
+			}
+		}
+		for _, eg := range n.Examples {
+			if eg != nil {
+				children = append(children, eg)
+			}
+		}
+	case *Type:
+		for _, m := range n.Methods {
+			if m != nil {
+				children = append(children, m)
+			}
+		}
+		for _, f := range n.Fields {
+			if f != nil {
+				children = append(children, f)
+			}
+		}
+		for _, o := range n.Operators {
+			if o != nil {
+				children = append(children, o)
+			}
+		}
+	}
+	return children
+}
+
+type inspector func(Node, Path) bool
+
+func (f inspector) Visit(node Node, path Path) Visitor {
+	if f(node, path) {
+		return f
+	}
+	return nil
+}
+
+// Inspect traverses an outline model in depth-first order: It starts by
+// calling f(node, path); node must not be nil. If f returns true, Inspect
+// invokes f recursively for each of the non-nil children of node, followed
+// by a call of f(nil, path)
+func Inspect(node Node, f func(Node, Path) bool) {
+	Walk(inspector(f), node)
+}
@@ -0,0 +1,157 @@
+package lib
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const walkText = `
+outline: geo
+  functions:
+    point(lat,lng float) point
+      params:
+        lat float
+        lng float
+      examples:
+        origin
+          code:
+            geo.point(0, 0)
+  types:
+    point
+      methods:
+        buffer(r float) polygon
+          params:
+            r float
+      fields:
+        x float
+      operators:
+        point - point = float`
+
+func nodeName(n Node) string {
+	switch x := n.(type) {
+	case Docs:
+		return "docs"
+	case *Doc:
+		return "doc " + x.Name
+	case *Function:
+		return "function " + x.Name()
+	case *Param:
+		return "param " + x.Name
+	case *Type:
+		return "type " + x.Name
+	case *Field:
+		return "field " + x.Name
+	case *Operator:
+		return "operator " + x.Opr
+	case *Example:
+		return "example " + x.Name
+	case nil:
+		return "nil"
+	}
+	return fmt.Sprintf("%T", n)
+}
+
+func TestInspect(t *testing.T) {
+	docs, err := Parse(strings.NewReader(walkText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	Inspect(docs, func(n Node, path Path) bool {
+		if n != nil {
+			got = append(got, fmt.Sprintf("%s (%d)", nodeName(n), len(path)))
+		}
+		return true
+	})
+	expect := []string{
+		"docs (0)",
+		"doc geo (1)",
+		"function point (2)",
+		"param lat (3)",
+		"param lng (3)",
+		"example origin (3)",
+		"type point (2)",
+		"function buffer (3)",
+		"param r (4)",
+		"field x (3)",
+		"operator point - point = float (3)",
+	}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("visit order mismatch (-want +got):\n%s", diff)
+	}
+
+	// returning false skips children
+	got = nil
+	Inspect(docs[0], func(n Node, path Path) bool {
+		if n != nil {
+			got = append(got, nodeName(n))
+		}
+		_, isType := n.(*Type)
+		return !isType
+	})
+	expect = []string{"doc geo", "function point", "param lat", "param lng", "example origin", "type point"}
+	if diff := cmp.Diff(expect, got); diff != "" {
+		t.Errorf("pruned visit mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestPath(t *testing.T) {
+	docs, err := Parse(strings.NewReader(walkText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []Path
+	Inspect(docs, func(n Node, path Path) bool {
+		if p, ok := n.(*Param); ok && p.Name == "r" {
+			paths = append(paths, path)
+		}
+		if p, ok := n.(*Param); ok && p.Name == "lat" {
+			paths = append(paths, path)
+		}
+		return true
+	})
+	if len(paths) != 2 {
+		t.Fatalf("expected 2 paths, got %d", len(paths))
+	}
+
+	lat, r := paths[0], paths[1]
+	if lat.Doc() != docs[0] || lat.Type() != nil || lat.Function() != docs[0].Functions[0] {
+		t.Errorf("unexpected context for module function param: %v", lat)
+	}
+	typ := docs[0].Types[0]
+	if r.Doc() != docs[0] || r.Type() != typ || r.Function() != typ.Methods[0] || r.Parent() != typ.Methods[0] {
+		t.Errorf("unexpected context for method param: %v", r)
+	}
+	if (Path{}).Parent() != nil {
+		t.Error("expected an empty path to have no parent")
+	}
+}
+
+type countVisitor map[string]int
+
+func (c countVisitor) Visit(n Node, path Path) Visitor {
+	c[nodeName(n)]++
+	return c
+}
+
+func TestWalk(t *testing.T) {
+	docs, err := Parse(strings.NewReader(walkText))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := countVisitor{}
+	Walk(counts, docs)
+	// one trailing nil visit for every node
+	if counts["nil"] != 11 {
+		t.Errorf("expected 11 nil visits, got %d", counts["nil"])
+	}
+	if len(docs[0].Examples()) != 1 {
+		t.Errorf("expected doc to have 1 example, got %d", len(docs[0].Examples()))
+	}
+}